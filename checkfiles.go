@@ -0,0 +1,134 @@
+package gorphan
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/graph"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+)
+
+// dropIgnoredCheckFiles removes orphans matched by ignore-check-file rules
+// and refreshes the relative paths. Rules and relative paths refer to the
+// deepest scan dir holding each orphan.
+func dropIgnoredCheckFiles(scanDirs []string, analysis *graph.Analysis, rules []string) error {
+	orphans, err := filterIgnoredCheckFiles(scanDirs, analysis.Orphans, rules)
+	if err != nil {
+		return err
+	}
+	sort.Strings(orphans)
+	relative, err := toRelativeSlash(scanDirs, orphans)
+	if err != nil {
+		return err
+	}
+	analysis.Orphans = orphans
+	analysis.OrphansRelative = relative
+
+	orphanAssets, err := filterIgnoredCheckFiles(scanDirs, analysis.OrphanAssets, rules)
+	if err != nil {
+		return err
+	}
+	sort.Strings(orphanAssets)
+	relativeAssets, err := toRelativeSlash(scanDirs, orphanAssets)
+	if err != nil {
+		return err
+	}
+	analysis.OrphanAssets = orphanAssets
+	analysis.OrphanAssetsRelative = relativeAssets
+	return nil
+}
+
+func filterIgnoredCheckFiles(scanDirs []string, orphanFiles []string, rules []string) ([]string, error) {
+	if len(orphanFiles) == 0 || len(rules) == 0 {
+		return orphanFiles, nil
+	}
+
+	dirsAbs, err := normalizePaths("", scanDirs)
+	if err != nil {
+		return nil, fmt.Errorf("resolve scan dir: %w", err)
+	}
+
+	filtered := make([]string, 0, len(orphanFiles))
+	for _, orphan := range orphanFiles {
+		scanDir, ok := pathutil.ContainingDir(dirsAbs, orphan)
+		if !ok {
+			scanDir = dirsAbs[0]
+		}
+		ignored, err := isIgnoredCheckFile(scanDir, orphan, rules)
+		if err != nil {
+			return nil, err
+		}
+		if ignored {
+			continue
+		}
+		filtered = append(filtered, orphan)
+	}
+	return filtered, nil
+}
+
+func isIgnoredCheckFile(scanDir, file string, rules []string) (bool, error) {
+	fileAbs, err := pathutil.NormalizeAbs(file)
+	if err != nil {
+		return false, fmt.Errorf("resolve orphan file path %q: %w", file, err)
+	}
+	rel, err := filepath.Rel(scanDir, fileAbs)
+	if err != nil {
+		return false, fmt.Errorf("resolve orphan file relative path %q: %w", fileAbs, err)
+	}
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	base := filepath.Base(fileAbs)
+
+	for _, rawRule := range rules {
+		ruleRaw := strings.TrimSpace(rawRule)
+		if ruleRaw == "" {
+			continue
+		}
+		rule := normalizeIgnoreCheckRule(scanDir, ruleRaw)
+		if strings.Contains(rule, "/") {
+			if rel == rule {
+				return true, nil
+			}
+			continue
+		}
+		if base == rule {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func normalizeIgnoreCheckRule(scanDir, rule string) string {
+	if filepath.IsAbs(rule) {
+		absRule := filepath.Clean(rule)
+		if rel, err := filepath.Rel(scanDir, absRule); err == nil {
+			rel = filepath.Clean(rel)
+			if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				rule = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(rule))
+}
+
+func toRelativeSlash(baseDirs []string, files []string) ([]string, error) {
+	basesAbs, err := normalizePaths("", baseDirs)
+	if err != nil {
+		return nil, fmt.Errorf("resolve base directory: %w", err)
+	}
+
+	relative := make([]string, 0, len(files))
+	for _, file := range files {
+		abs, err := pathutil.NormalizeAbs(file)
+		if err != nil {
+			return nil, fmt.Errorf("resolve file path %q: %w", file, err)
+		}
+		rel, err := pathutil.RelativeSlashIn(basesAbs, abs)
+		if err != nil {
+			return nil, fmt.Errorf("convert orphan path to relative: %w", err)
+		}
+		relative = append(relative, rel)
+	}
+	return relative, nil
+}
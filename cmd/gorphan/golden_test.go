@@ -7,7 +7,7 @@ import (
 	"strings"
 	"testing"
 
-	"gorphan/internal/testutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
 )
 
 func TestGolden_TextOutput(t *testing.T) {
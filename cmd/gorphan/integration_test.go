@@ -3,11 +3,12 @@ package main
 import (
 	"bytes"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"gorphan/internal/testutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
 )
 
 func TestIntegration_NoOrphans(t *testing.T) {
@@ -92,3 +93,464 @@ func TestIntegration_InvalidRootValidationFailure(t *testing.T) {
 		t.Fatalf("expected root validation error, got: %s", stderr.String())
 	}
 }
+
+func TestIntegration_AssetOrphans(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "![logo](./img/logo.png)\n\n<img src=\"img/banner.svg\">\n")
+	testutil.MustWrite(t, filepath.Join(docs, "img", "logo.png"), "png")
+	testutil.MustWrite(t, filepath.Join(docs, "img", "banner.svg"), "svg")
+	testutil.MustWrite(t, filepath.Join(docs, "img", "unused.png"), "png")
+	testutil.MustWrite(t, filepath.Join(docs, "files", "old.pdf"), "pdf")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", docs, "--asset", "*.png", "--asset", "*.svg", "--asset", "files/*.pdf"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit 1 for orphan assets, got %d; stderr=%s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "No orphan markdown files found.") {
+		t.Fatalf("expected no orphan pages, got: %s", out)
+	}
+	if !strings.Contains(out, "Orphan assets (2):\n- files/old.pdf\n- img/unused.png") {
+		t.Fatalf("expected orphan assets section, got: %s", out)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", docs, "--asset", "*.png", "--asset", "*.svg", "--ignore-check-file", "unused.png"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit 0 when orphan asset is ignored, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestIntegration_RootRelativeLinksUseSiteRoot(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "[Guide](/docs/guide.md)\n[Setup](/setup.md)\n")
+	testutil.MustWrite(t, filepath.Join(docs, "guide.md"), "# guide")
+	testutil.MustWrite(t, filepath.Join(docs, "setup.md"), "# setup")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", docs, "--site-root", dir, "--unresolved", "warn"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d; stderr=%s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "- guide.md") || !strings.Contains(stdout.String(), "- setup.md") {
+		t.Fatalf("expected only setup.md orphaned when site root is the parent, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", docs, "--site-root", docs, "--unresolved", "warn"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d; stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "- guide.md") || strings.Contains(stdout.String(), "- setup.md") {
+		t.Fatalf("expected only guide.md orphaned when site root is --dir, got: %s", stdout.String())
+	}
+}
+
+func TestIntegration_ExtensionlessLinksResolveToIndexFiles(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "[Setup](./setup)\n[API](./api/)\n")
+	testutil.MustWrite(t, filepath.Join(docs, "setup.md"), "# setup")
+	testutil.MustWrite(t, filepath.Join(docs, "api", "README.md"), "# api")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", docs}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", docs, "--resolve", ".md"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d; stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "- api/README.md") || strings.Contains(stdout.String(), "- setup.md") {
+		t.Fatalf("expected only api/README.md orphaned without index candidates, got: %s", stdout.String())
+	}
+}
+
+func TestIntegration_BrokenFragmentsFollowUnresolvedMode(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "[Install](./guide.md#installing-gorphan)\n")
+	testutil.MustWrite(t, filepath.Join(docs, "guide.md"), "# Guide\n\n## Installing  gorphan\n")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", docs}, &stdout, &stderr)
+	if code != 0 || stderr.Len() != 0 {
+		t.Fatalf("expected fragments unchecked by default, got %d; stderr=%s", code, stderr.String())
+	}
+
+	code = run([]string{"--root", root, "--dir", docs, "--anchors", "github"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit 1 with github slugs, got %d; stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "error: broken link fragment: index.md:1:1 -> ./guide.md#installing-gorphan") {
+		t.Fatalf("expected broken fragment error, got: %s", stderr.String())
+	}
+
+	for _, style := range []string{"gitlab", "mkdocs", "none"} {
+		stdout.Reset()
+		stderr.Reset()
+		code = run([]string{"--root", root, "--dir", docs, "--anchors", style}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("expected exit 0 with --anchors %s, got %d; stderr=%s", style, code, stderr.String())
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", docs, "--anchors", "kramdown"}, &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "anchors must be one of") {
+		t.Fatalf("expected invalid --anchors error, got %d; stderr=%s", code, stderr.String())
+	}
+}
+
+func TestIntegration_ShortestPathWikiLinks(t *testing.T) {
+	dir := t.TempDir()
+	vault := filepath.Join(dir, "vault")
+	root := filepath.Join(vault, "Home.md")
+	testutil.MustWrite(t, root, "[[Projects]] [[Daily]]\n")
+	testutil.MustWrite(t, filepath.Join(vault, "areas", "Projects.md"), "# projects")
+	testutil.MustWrite(t, filepath.Join(vault, "journal", "Daily.md"), "# daily")
+	testutil.MustWrite(t, filepath.Join(vault, "archive", "Daily.md"), "# old daily")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", vault, "--wikilinks", "shortest", "--unresolved", "report", "--format", "json"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d; stderr=%s", code, stderr.String())
+	}
+	out := stdout.String()
+	if strings.Contains(out, `"areas/Projects.md"`) {
+		t.Fatalf("expected basename match to link areas/Projects.md, got: %s", out)
+	}
+	if !strings.Contains(out, `"kind": "ambiguous-wikilink"`) || !strings.Contains(out, `"archive/Daily.md"`) || !strings.Contains(out, `"journal/Daily.md"`) {
+		t.Fatalf("expected ambiguous wikilink diagnostic with candidates, got: %s", out)
+	}
+}
+
+func TestIntegration_FrontMatterDrafts(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "[draft](./draft.md)\n")
+	testutil.MustWrite(t, filepath.Join(dir, "draft.md"), "---\ndraft: true\n---\n[old](./old.md)\n")
+	testutil.MustWrite(t, filepath.Join(dir, "old.md"), "# old")
+	testutil.MustWrite(t, filepath.Join(dir, "keep.md"), "---\ngorphan:\n  orphan: allowed\n---\n")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", dir, "--drafts", "no-links"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d; stderr=%s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "old.md") || strings.Contains(out, "keep.md") {
+		t.Fatalf("expected only old.md orphan, got: %s", out)
+	}
+
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", dir, "--drafts", "publish"}, &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "drafts must be one of") {
+		t.Fatalf("expected invalid --drafts error, got %d; stderr=%s", code, stderr.String())
+	}
+}
+
+func TestIntegration_MDXImportsAndJSXLinks(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.mdx")
+	testutil.MustWrite(t, root, "import Intro from './_intro.mdx'\n\n<Intro />\n\n<Button target=\"./setup.md\">Setup</Button>\n")
+	testutil.MustWrite(t, filepath.Join(dir, "_intro.mdx"), "<Link to=\"./guide.md\">Guide</Link>\n")
+	testutil.MustWrite(t, filepath.Join(dir, "guide.md"), "# guide")
+	testutil.MustWrite(t, filepath.Join(dir, "setup.md"), "# setup")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", dir, "--ext", ".md,.mdx", "--mdx-links", "Link:to,Button:target"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"--root", root, "--dir", dir, "--ext", ".md,.mdx"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "setup.md") || strings.Contains(stdout.String(), "guide.md") {
+		t.Fatalf("expected only setup.md orphan with default --mdx-links, got %d; stdout=%s", code, stdout.String())
+	}
+
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", dir, "--mdx-links", "Link"}, &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "--mdx-links") {
+		t.Fatalf("expected invalid --mdx-links error, got %d; stderr=%s", code, stderr.String())
+	}
+}
+
+func TestIntegration_HugoSections(t *testing.T) {
+	dir := t.TempDir()
+	content := filepath.Join(dir, "content")
+	root := filepath.Join(content, "_index.md")
+	testutil.MustWrite(t, root, "Welcome, see {{< relref \"docs/setup\" >}}.\n")
+	testutil.MustWrite(t, filepath.Join(content, "docs", "setup.md"), "# setup")
+	testutil.MustWrite(t, filepath.Join(content, "blog", "_index.md"), "# blog")
+	testutil.MustWrite(t, filepath.Join(content, "blog", "first.md"), "# first")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", content, "--hugo", "refs"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "blog/first.md") || strings.Contains(stdout.String(), "docs/setup.md") {
+		t.Fatalf("expected blog pages orphaned in refs mode, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"--root", root, "--dir", content, "--hugo", "sections"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit 0 in sections mode, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", content, "--hugo", "all"}, &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "hugo must be one of") {
+		t.Fatalf("expected invalid --hugo error, got %d; stderr=%s", code, stderr.String())
+	}
+}
+
+func TestIntegration_MkDocsNavRoots(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mkdocs.yml")
+	testutil.MustWrite(t, configPath, "site_name: Example\nnav:\n  - Home: index.md\n  - Guide:\n      - guide/install.md\n      - Usage: guide/usage.md\n")
+	testutil.MustWrite(t, filepath.Join(dir, "docs", "index.md"), "# home")
+	testutil.MustWrite(t, filepath.Join(dir, "docs", "guide", "install.md"), "[faq](../faq.md)")
+	testutil.MustWrite(t, filepath.Join(dir, "docs", "faq.md"), "# faq")
+	testutil.MustWrite(t, filepath.Join(dir, "docs", "stale.md"), "# stale")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--mkdocs", configPath, "--unresolved", "warn"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "stale.md") || strings.Contains(stdout.String(), "faq.md") || strings.Contains(stdout.String(), "install.md") {
+		t.Fatalf("expected only stale.md orphaned, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "unresolved nav entry: ../mkdocs.yml:6:16 -> guide/usage.md") {
+		t.Fatalf("expected missing nav page warning, got stderr=%s", stderr.String())
+	}
+}
+
+func TestIntegration_SummaryIsAuthoritative(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	summary := filepath.Join(src, "SUMMARY.md")
+	testutil.MustWrite(t, summary, "# Summary\n\n[Intro](README.md)\n\n# Guide\n\n- [Setup](setup.md)\n- [Later]()\n\n---\n")
+	testutil.MustWrite(t, filepath.Join(src, "README.md"), "# intro")
+	testutil.MustWrite(t, filepath.Join(src, "setup.md"), "see [notes](notes.md)")
+	testutil.MustWrite(t, filepath.Join(src, "notes.md"), "# notes")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--summary", summary, "--unresolved", "warn"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "notes.md") || strings.Contains(stdout.String(), "setup.md") {
+		t.Fatalf("expected chapter missing from SUMMARY to be orphaned, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "draft chapter without file: SUMMARY.md:8:3 -> Later") {
+		t.Fatalf("expected draft chapter warning, got stderr=%s", stderr.String())
+	}
+
+	stderr.Reset()
+	code = run([]string{"--summary", summary, "--mkdocs", filepath.Join(dir, "mkdocs.yml")}, &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "cannot be combined") {
+		t.Fatalf("expected --summary/--mkdocs conflict, got %d; stderr=%s", code, stderr.String())
+	}
+}
+
+func TestIntegration_MySTToctree(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "# Docs\n\n```{toctree}\n:maxdepth: 1\n\nguide\n```\n")
+	testutil.MustWrite(t, filepath.Join(dir, "guide.md"), "See {doc}`faq`.")
+	testutil.MustWrite(t, filepath.Join(dir, "faq.md"), "# faq")
+	testutil.MustWrite(t, filepath.Join(dir, "stray.md"), "# stray")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", dir, "--myst"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "stray.md") || strings.Contains(stdout.String(), "guide.md") || strings.Contains(stdout.String(), "faq.md") {
+		t.Fatalf("expected only stray.md orphaned, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"--root", root, "--dir", dir}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "guide.md") {
+		t.Fatalf("expected toctree to be ignored without --myst, got %d; stdout=%s", code, stdout.String())
+	}
+}
+
+func TestIntegration_IncludeDirectives(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "# Home\n\n--8<-- \"_snippets/note.md\"\n\n<!-- include: _partials/table.md -->\n")
+	testutil.MustWrite(t, filepath.Join(dir, "_snippets", "note.md"), "note")
+	testutil.MustWrite(t, filepath.Join(dir, "_partials", "table.md"), "| a |")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", dir, "--includes", "snippets,markdown-include,comment"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected included fragments to be reachable, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	code = run([]string{"--root", root, "--dir", dir}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "_snippets/note.md") {
+		t.Fatalf("expected fragments orphaned without --includes, got %d; stdout=%s", code, stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"--root", root, "--dir", dir, "--includes", "none"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "_snippets/note.md") {
+		t.Fatalf("expected fragments orphaned with --includes none, got %d; stdout=%s", code, stdout.String())
+	}
+
+	stderr.Reset()
+	code = run([]string{"--root", root, "--dir", dir, "--includes", "transclude"}, &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "--includes: invalid include pattern") {
+		t.Fatalf("expected invalid --includes error, got %d; stderr=%s", code, stderr.String())
+	}
+}
+
+func TestIntegration_FollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	testutil.MustWrite(t, filepath.Join(docs, "index.md"), "[page](alias/page.md)")
+	testutil.MustWrite(t, filepath.Join(docs, "real", "page.md"), "[home](../index.md)")
+	if err := os.Symlink(filepath.Join(docs, "real"), filepath.Join(docs, "alias")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(docs, filepath.Join(docs, "real", "loop")); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+	linkedDocs := filepath.Join(dir, "linked-docs")
+	if err := os.Symlink(docs, linkedDocs); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+	root := filepath.Join(linkedDocs, "index.md")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if code := run([]string{"--root", root, "--dir", linkedDocs}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1 without --follow-symlinks, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code := run([]string{"--root", root, "--dir", linkedDocs, "--follow-symlinks"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit 0 with --follow-symlinks, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "No orphan markdown files found.") {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestIntegration_GitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "# root")
+	testutil.MustWrite(t, filepath.Join(dir, ".git", "info", "exclude"), "scratch.md\n")
+	testutil.MustWrite(t, filepath.Join(docs, ".gitignore"), "build/\n")
+	testutil.MustWrite(t, filepath.Join(docs, "build", "generated.md"), "# generated")
+	testutil.MustWrite(t, filepath.Join(docs, "scratch.md"), "# scratch")
+	testutil.MustWrite(t, filepath.Join(docs, "stale.md"), "# stale")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", docs}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "Orphan markdown files (3):") {
+		t.Fatalf("expected 3 orphans without --gitignore, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"--root", root, "--dir", docs, "--gitignore"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "Orphan markdown files (1):\n- stale.md") {
+		t.Fatalf("expected only stale.md with --gitignore, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestIntegration_GorphanIgnoreWithNegation(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "# root")
+	testutil.MustWrite(t, filepath.Join(docs, ".gorphanignore"), "# drafts are never linked\narchive/\n*.draft.md\n")
+	testutil.MustWrite(t, filepath.Join(docs, "archive", "old.md"), "# old")
+	testutil.MustWrite(t, filepath.Join(docs, "wip.draft.md"), "# wip")
+	testutil.MustWrite(t, filepath.Join(docs, "keep.draft.md"), "# keep")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", docs}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected .gorphanignore to skip every orphan, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"--root", root, "--dir", docs, "--ignore", "!keep.draft.md"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "Orphan markdown files (1):\n- keep.draft.md") {
+		t.Fatalf("expected --ignore negation to re-include keep.draft.md, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestIntegration_GitTracked(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git unavailable: %v", err)
+	}
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "# root")
+	testutil.MustWrite(t, filepath.Join(dir, ".gitignore"), "*.log.md\n")
+	testutil.MustWrite(t, filepath.Join(dir, "tracked.md"), "# tracked")
+	mustGit(t, dir, "init", "-q")
+	mustGit(t, dir, "add", ".")
+	testutil.MustWrite(t, filepath.Join(dir, "untracked.md"), "# untracked")
+	testutil.MustWrite(t, filepath.Join(dir, "build.log.md"), "# log")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", dir, "--git-tracked"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "Orphan markdown files (1):\n- tracked.md") {
+		t.Fatalf("expected only tracked.md with --git-tracked, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"--root", root, "--dir", dir, "--git-untracked"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "Orphan markdown files (2):\n- tracked.md\n- untracked.md") {
+		t.Fatalf("expected tracked.md and untracked.md with --git-untracked, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+	outside := t.TempDir()
+	testutil.MustWrite(t, filepath.Join(outside, "index.md"), "# root")
+	code = run([]string{"--root", filepath.Join(outside, "index.md"), "--dir", outside, "--git-tracked"}, &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "list git files") {
+		t.Fatalf("expected git error outside a repository, got %d; stderr=%s", code, stderr.String())
+	}
+}
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
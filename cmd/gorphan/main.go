@@ -1,19 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
-
-	configpkg "gorphan/internal/config"
-	"gorphan/internal/graph"
-	"gorphan/internal/pathutil"
-	"gorphan/internal/report"
-	"gorphan/internal/scanner"
+	"time"
+
+	"github.com/shogo-nakano-desu/gorphan"
+	configpkg "github.com/shogo-nakano-desu/gorphan/internal/config"
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/report"
+	"github.com/shogo-nakano-desu/gorphan/internal/scanner"
 )
 
 type multiFlag []string
@@ -29,26 +32,43 @@ func (m *multiFlag) Set(value string) error {
 
 type config struct {
 	Root             string
+	Roots            []string
 	Dir              string
+	Dirs             []string
+	SiteRoot         string
 	Ext              string
+	Resolve          string
 	Ignore           []string
 	IgnoreCheckFiles []string
+	Assets           []string
+	GitIgnore        bool
+	GitTracked       bool
+	GitUntracked     bool
+	FollowSymlinks   bool
 	Format           string
 	Verbose          bool
 	Unresolved       string
+	Anchors          string
+	WikiLinks        string
+	Drafts           string
+	MDXLinks         string
+	Includes         string
+	Hugo             string
+	Jekyll           bool
+	MyST             bool
+	MkDocs           string
+	Summary          string
 	GraphFormat      string
 	Workers          int
 	MaxGraphNodes    int
+	Timeout          time.Duration
 	ConfigPath       string
 }
 
 type runState struct {
 	cfg              config
-	extensions       []string
-	files            []string
-	linkGraph        *graph.Graph
-	analysis         *graph.Analysis
-	warnings         []string
+	result           *gorphan.Result
+	warnings         []gorphan.Warning
 	graphText        string
 	unresolvedFailed bool
 }
@@ -63,16 +83,30 @@ func run(args []string, stdout io.Writer, stderr io.Writer) int {
 		return 2
 	}
 
-	state := &runState{cfg: cfg, extensions: scanner.NormalizeExtensions(cfg.Ext)}
-	if err := state.scanFiles(); err != nil {
-		return writeRunError(stderr, err)
+	ctx := context.Background()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
 	}
-	if err := state.buildAndAnalyzeGraph(); err != nil {
-		return writeRunError(stderr, err)
+	opts := cfg.options()
+	progress := newProgressPrinter(stderr)
+	if progress != nil {
+		opts.Progress = progress.update
+	}
+
+	state := &runState{cfg: cfg}
+	result, err := gorphan.Check(ctx, opts)
+	if progress != nil {
+		progress.clear()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", cfg.Timeout)
 	}
-	if err := state.postProcessOrphans(); err != nil {
+	if err != nil {
 		return writeRunError(stderr, err)
 	}
+	state.result = result
 	if err := state.prepareGraphText(stderr); err != nil {
 		return writeRunError(stderr, err)
 	}
@@ -95,57 +129,39 @@ func writeRunError(stderr io.Writer, err error) int {
 	return 2
 }
 
-func (s *runState) scanFiles() error {
-	files, err := scanner.Scan(scanner.Options{
-		Dir:        s.cfg.Dir,
-		Extensions: s.extensions,
-		Ignore:     s.cfg.Ignore,
-	})
-	if err != nil {
-		return err
+func (c config) options() gorphan.Options {
+	return gorphan.Options{
+		Root:             c.Root,
+		Roots:            c.Roots,
+		Dir:              c.Dir,
+		Dirs:             c.Dirs,
+		SiteRoot:         c.SiteRoot,
+		Extensions:       scanner.NormalizeExtensions(c.Ext),
+		Resolve:          pathutil.SplitList(c.Resolve),
+		Ignore:           c.Ignore,
+		IgnoreCheckFiles: c.IgnoreCheckFiles,
+		Assets:           c.Assets,
+		GitIgnore:        c.GitIgnore,
+		GitTracked:       c.GitTracked,
+		GitUntracked:     c.GitUntracked,
+		FollowSymlinks:   c.FollowSymlinks,
+		Anchors:          gorphan.AnchorStyle(c.Anchors),
+		WikiLinks:        gorphan.WikiLinkMode(c.WikiLinks),
+		Drafts:           gorphan.DraftMode(c.Drafts),
+		JSXLinks:         pathutil.SplitList(c.MDXLinks),
+		Includes:         pathutil.SplitList(c.Includes),
+		Hugo:             gorphan.HugoMode(c.Hugo),
+		Jekyll:           c.Jekyll,
+		MyST:             c.MyST,
+		MkDocs:           c.MkDocs,
+		Summary:          c.Summary,
+		Workers:          c.Workers,
 	}
-	s.files = files
-	return nil
-}
-
-func (s *runState) buildAndAnalyzeGraph() error {
-	linkGraph, err := graph.Build(graph.Options{
-		Root:       s.cfg.Root,
-		ScanDir:    s.cfg.Dir,
-		Files:      s.files,
-		Extensions: s.extensions,
-		MaxWorkers: s.cfg.Workers,
-	})
-	if err != nil {
-		return err
-	}
-	analysis, err := graph.Analyze(linkGraph, s.cfg.Dir, s.files)
-	if err != nil {
-		return err
-	}
-	s.linkGraph = linkGraph
-	s.analysis = analysis
-	return nil
-}
-
-func (s *runState) postProcessOrphans() error {
-	orphans, err := filterIgnoredCheckFiles(s.cfg.Dir, s.analysis.Orphans, s.cfg.IgnoreCheckFiles)
-	if err != nil {
-		return err
-	}
-	sort.Strings(orphans)
-	relative, err := toRelativeSlash(s.cfg.Dir, orphans)
-	if err != nil {
-		return err
-	}
-	s.analysis.Orphans = orphans
-	s.analysis.OrphansRelative = relative
-	return nil
 }
 
 func (s *runState) prepareGraphText(stderr io.Writer) error {
 	s.graphText = ""
-	graphNodeCount := len(s.linkGraph.Adjacency)
+	graphNodeCount := len(s.result.Graph.Adjacency)
 	graphLimited := s.cfg.GraphFormat != "none" && s.cfg.MaxGraphNodes > 0 && graphNodeCount > s.cfg.MaxGraphNodes
 	if graphLimited {
 		_, err := fmt.Fprintf(stderr, "warning: graph export skipped: node count %d exceeds --max-graph-nodes=%d\n", graphNodeCount, s.cfg.MaxGraphNodes)
@@ -155,9 +171,9 @@ func (s *runState) prepareGraphText(stderr io.Writer) error {
 	var err error
 	switch s.cfg.GraphFormat {
 	case "dot":
-		s.graphText, err = graph.ExportDOT(s.linkGraph, s.cfg.Dir)
+		s.graphText, err = gorphan.ExportDOT(s.result.Graph, s.cfg.Dirs...)
 	case "mermaid":
-		s.graphText, err = graph.ExportMermaid(s.linkGraph, s.cfg.Dir)
+		s.graphText, err = gorphan.ExportMermaid(s.result.Graph, s.cfg.Dirs...)
 	}
 	return err
 }
@@ -168,36 +184,60 @@ func (s *runState) renderVerbose(stdout io.Writer) error {
 	}
 
 	totalEdges := 0
-	for _, targets := range s.linkGraph.Adjacency {
+	for _, targets := range s.result.Graph.Adjacency {
 		totalEdges += len(targets)
 	}
 
 	lines := []string{
 		"Validated inputs:",
-		fmt.Sprintf("- root: %s", s.cfg.Root),
-		fmt.Sprintf("- dir: %s", s.cfg.Dir),
+		fmt.Sprintf("- root: %s", strings.Join(s.cfg.Roots, ", ")),
+		fmt.Sprintf("- dir: %s", strings.Join(s.cfg.Dirs, ", ")),
+		fmt.Sprintf("- site-root: %s", s.cfg.SiteRoot),
 		fmt.Sprintf("- ext: %s", s.cfg.Ext),
+		fmt.Sprintf("- resolve: %s", s.cfg.Resolve),
 		fmt.Sprintf("- ignore: %v", s.cfg.Ignore),
 		fmt.Sprintf("- ignore-check-files: %v", s.cfg.IgnoreCheckFiles),
+		fmt.Sprintf("- gitignore: %t", s.cfg.GitIgnore),
+		fmt.Sprintf("- git-tracked: %t", s.cfg.GitTracked),
+		fmt.Sprintf("- git-untracked: %t", s.cfg.GitUntracked),
+		fmt.Sprintf("- follow-symlinks: %t", s.cfg.FollowSymlinks),
+		fmt.Sprintf("- assets: %v", s.cfg.Assets),
 		fmt.Sprintf("- format: %s", s.cfg.Format),
 		fmt.Sprintf("- unresolved: %s", s.cfg.Unresolved),
+		fmt.Sprintf("- anchors: %s", s.cfg.Anchors),
+		fmt.Sprintf("- wikilinks: %s", s.cfg.WikiLinks),
+		fmt.Sprintf("- drafts: %s", s.cfg.Drafts),
+		fmt.Sprintf("- mdx-links: %s", s.cfg.MDXLinks),
+		fmt.Sprintf("- includes: %s", s.cfg.Includes),
+		fmt.Sprintf("- hugo: %s", s.cfg.Hugo),
+		fmt.Sprintf("- jekyll: %t", s.cfg.Jekyll),
+		fmt.Sprintf("- myst: %t", s.cfg.MyST),
+		fmt.Sprintf("- mkdocs: %s", s.cfg.MkDocs),
+		fmt.Sprintf("- summary: %s", s.cfg.Summary),
 		fmt.Sprintf("- graph: %s", s.cfg.GraphFormat),
 		fmt.Sprintf("- max-graph-nodes: %d", s.cfg.MaxGraphNodes),
 		fmt.Sprintf("- workers: %d", s.cfg.Workers),
-		fmt.Sprintf("- scanned markdown files: %d", len(s.files)),
-		fmt.Sprintf("- graph nodes: %d", len(s.linkGraph.Adjacency)),
+		fmt.Sprintf("- timeout: %s", s.cfg.Timeout),
+		fmt.Sprintf("- scanned markdown files: %d", len(s.result.Files)),
+		fmt.Sprintf("- graph nodes: %d", len(s.result.Graph.Adjacency)),
 		fmt.Sprintf("- graph edges: %d", totalEdges),
-		fmt.Sprintf("- reachable files: %d", len(s.analysis.Reachable)),
-		fmt.Sprintf("- orphan files: %d", len(s.analysis.Orphans)),
-		"",
+		fmt.Sprintf("- reachable files: %d", len(s.result.Analysis.Reachable)),
+		fmt.Sprintf("- orphan files: %d", len(s.result.Analysis.Orphans)),
+	}
+	if len(s.cfg.Assets) > 0 {
+		lines = append(lines,
+			fmt.Sprintf("- scanned assets: %d", len(s.result.Assets)),
+			fmt.Sprintf("- orphan assets: %d", len(s.result.Analysis.OrphanAssets)),
+		)
 	}
+	lines = append(lines, "")
 
 	_, err := fmt.Fprintln(stdout, strings.Join(lines, "\n"))
 	return err
 }
 
 func (s *runState) applyWarningPolicy(stderr io.Writer) error {
-	s.warnings = append([]string(nil), s.linkGraph.Warnings...)
+	s.warnings = append([]gorphan.Warning(nil), s.result.Graph.Warnings...)
 	s.unresolvedFailed = false
 
 	switch s.cfg.Unresolved {
@@ -205,7 +245,7 @@ func (s *runState) applyWarningPolicy(stderr io.Writer) error {
 		s.warnings = nil
 	case "warn":
 		for _, warning := range s.warnings {
-			if _, err := fmt.Fprintf(stderr, "warning: %s\n", warning); err != nil {
+			if _, err := fmt.Fprintf(stderr, "warning: %s\n", warning.Format(s.cfg.Dirs...)); err != nil {
 				return err
 			}
 		}
@@ -214,7 +254,7 @@ func (s *runState) applyWarningPolicy(stderr io.Writer) error {
 			s.unresolvedFailed = true
 		}
 		for _, warning := range s.warnings {
-			if _, err := fmt.Fprintf(stderr, "error: %s\n", warning); err != nil {
+			if _, err := fmt.Fprintf(stderr, "error: %s\n", warning.Format(s.cfg.Dirs...)); err != nil {
 				return err
 			}
 		}
@@ -227,15 +267,20 @@ func (s *runState) applyWarningPolicy(stderr io.Writer) error {
 
 func (s *runState) renderReport(stdout io.Writer) error {
 	rep := report.Result{
-		Root:     s.cfg.Root,
-		Dir:      s.cfg.Dir,
-		Orphans:  s.analysis.OrphansRelative,
-		Warnings: s.warnings,
-		Graph:    s.graphText,
+		Root:         s.cfg.Root,
+		Dir:          s.cfg.Dir,
+		Roots:        morePaths(s.cfg.Roots),
+		Dirs:         morePaths(s.cfg.Dirs),
+		Orphans:      s.result.Analysis.OrphansRelative,
+		OrphanAssets: s.result.Analysis.OrphanAssetsRelative,
+		Warnings:     s.reportWarnings(),
+		Graph:        s.graphText,
 		Summary: report.Summary{
-			Scanned:   len(s.files),
-			Reachable: len(s.analysis.Reachable),
-			Orphans:   len(s.analysis.Orphans),
+			Scanned:      len(s.result.Files),
+			Reachable:    len(s.result.Analysis.Reachable),
+			Orphans:      len(s.result.Analysis.Orphans),
+			Assets:       len(s.result.Assets),
+			OrphanAssets: len(s.result.Analysis.OrphanAssets),
 		},
 	}
 
@@ -253,8 +298,61 @@ func (s *runState) renderReport(stdout io.Writer) error {
 	}
 }
 
+// morePaths returns paths when there is more than one, so single-root and
+// single-dir reports keep their shape.
+func morePaths(paths []string) []string {
+	if len(paths) < 2 {
+		return nil
+	}
+	return paths
+}
+
+func (s *runState) reportWarnings() []report.Warning {
+	if len(s.warnings) == 0 {
+		return nil
+	}
+	out := make([]report.Warning, 0, len(s.warnings))
+	for _, warning := range s.warnings {
+		out = append(out, report.Warning{
+			Kind:        string(warning.Kind),
+			File:        relativeOrAbsolute(s.cfg.Dirs, warning.Source),
+			Line:        warning.Link.Line,
+			Column:      warning.Link.Column,
+			Destination: warning.Link.Destination,
+			Target:      relativeOrAbsolute(s.cfg.Dirs, warning.Target),
+			Candidates:  relativeOrAbsoluteMany(s.cfg.Dirs, warning.Candidates),
+			Message:     warning.Format(s.cfg.Dirs...),
+		})
+	}
+	return out
+}
+
+func relativeOrAbsoluteMany(baseDirs []string, paths []string) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		out = append(out, relativeOrAbsolute(baseDirs, path))
+	}
+	return out
+}
+
+// relativeOrAbsolute makes path relative to the deepest of baseDirs holding
+// it, the way orphans are reported.
+func relativeOrAbsolute(baseDirs []string, path string) string {
+	if path == "" {
+		return ""
+	}
+	rel, err := pathutil.RelativeSlashIn(baseDirs, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return rel
+}
+
 func (s *runState) exitCode() int {
-	if len(s.analysis.Orphans) > 0 || s.unresolvedFailed {
+	if len(s.result.Analysis.Orphans) > 0 || len(s.result.Analysis.OrphanAssets) > 0 || s.unresolvedFailed {
 		return 1
 	}
 	return 0
@@ -262,8 +360,11 @@ func (s *runState) exitCode() int {
 
 func parseArgs(args []string, stderr io.Writer) (config, error) {
 	var cfg config
+	var roots multiFlag
+	var dirs multiFlag
 	var ignores multiFlag
 	var ignoreCheckFiles multiFlag
+	var assets multiFlag
 	cfgPath, cfgExplicit, err := configpkg.FindConfigArg(args)
 	if err != nil {
 		return config{}, err
@@ -275,18 +376,50 @@ func parseArgs(args []string, stderr io.Writer) (config, error) {
 
 	cfg = config{
 		Root:             fileCfg.Root,
+		Roots:            append([]string(nil), fileCfg.Roots...),
 		Dir:              fileCfg.Dir,
+		Dirs:             append([]string(nil), fileCfg.Dirs...),
+		SiteRoot:         fileCfg.SiteRoot,
 		Ext:              fileCfg.Ext,
+		Resolve:          fileCfg.Resolve,
 		Ignore:           append([]string(nil), fileCfg.Ignore...),
 		IgnoreCheckFiles: append([]string(nil), fileCfg.IgnoreCheckFiles...),
+		Assets:           append([]string(nil), fileCfg.Assets...),
 		Format:           fileCfg.Format,
 		Unresolved:       fileCfg.Unresolved,
+		Anchors:          fileCfg.Anchors,
+		WikiLinks:        fileCfg.WikiLinks,
+		Drafts:           fileCfg.Drafts,
+		MDXLinks:         fileCfg.MDXLinks,
+		Includes:         fileCfg.Includes,
+		Hugo:             fileCfg.Hugo,
+		MkDocs:           fileCfg.MkDocs,
+		Summary:          fileCfg.Summary,
 		GraphFormat:      fileCfg.Graph,
+		Timeout:          fileCfg.Timeout,
 		ConfigPath:       cfgPath,
 	}
 	if fileCfg.Verbose != nil {
 		cfg.Verbose = *fileCfg.Verbose
 	}
+	if fileCfg.GitIgnore != nil {
+		cfg.GitIgnore = *fileCfg.GitIgnore
+	}
+	if fileCfg.GitTracked != nil {
+		cfg.GitTracked = *fileCfg.GitTracked
+	}
+	if fileCfg.GitUntracked != nil {
+		cfg.GitUntracked = *fileCfg.GitUntracked
+	}
+	if fileCfg.FollowSymlinks != nil {
+		cfg.FollowSymlinks = *fileCfg.FollowSymlinks
+	}
+	if fileCfg.Jekyll != nil {
+		cfg.Jekyll = *fileCfg.Jekyll
+	}
+	if fileCfg.MyST != nil {
+		cfg.MyST = *fileCfg.MyST
+	}
 	if cfg.Ext == "" {
 		cfg.Ext = ".md,.markdown"
 	}
@@ -296,6 +429,24 @@ func parseArgs(args []string, stderr io.Writer) (config, error) {
 	if cfg.Unresolved == "" {
 		cfg.Unresolved = "fail"
 	}
+	if cfg.Anchors == "" {
+		cfg.Anchors = "none"
+	}
+	if cfg.WikiLinks == "" {
+		cfg.WikiLinks = "relative"
+	}
+	if cfg.Drafts == "" {
+		cfg.Drafts = "include"
+	}
+	if cfg.MDXLinks == "" {
+		cfg.MDXLinks = parser.DefaultJSXLinks
+	}
+	if cfg.Includes == "" {
+		cfg.Includes = "none"
+	}
+	if cfg.Hugo == "" {
+		cfg.Hugo = "none"
+	}
 	if cfg.GraphFormat == "" {
 		cfg.GraphFormat = "none"
 	}
@@ -308,20 +459,38 @@ func parseArgs(args []string, stderr io.Writer) (config, error) {
 
 	fs := flag.NewFlagSet("gorphan", flag.ContinueOnError)
 	fs.SetOutput(stderr)
-	fs.StringVar(&cfg.Root, "root", cfg.Root, "root markdown file (required)")
-	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "directory to scan recursively (default: current directory)")
+	fs.Var(&roots, "root", "root markdown file or glob; reachability starts from every root (repeatable, required)")
+	fs.Var(&dirs, "dir", "directory to scan recursively; the inventory is the union of every dir (repeatable, default: current directory)")
+	fs.StringVar(&cfg.SiteRoot, "site-root", cfg.SiteRoot, "directory that root-relative links (/path.md) resolve against (default: repository root, else --dir)")
 	fs.StringVar(&cfg.Ext, "ext", cfg.Ext, "comma-separated markdown extensions")
-	fs.Var(&ignores, "ignore", "ignore path prefix or glob (repeatable)")
+	fs.StringVar(&cfg.Resolve, "resolve", cfg.Resolve, "comma-separated ordered candidates for extensionless and directory links; suffixes are appended, /-prefixed names are looked up inside the directory (default: each --ext, then /index, /README and /_index with each --ext; empty disables)")
+	fs.Var(&ignores, "ignore", "ignore pattern in gitignore syntax, relative to --dir (repeatable)")
 	fs.Var(&ignoreCheckFiles, "ignore-check-file", "ignore orphan check for file by relative path or basename (repeatable)")
+	fs.Var(&assets, "asset", "asset glob to inventory for orphan detection, matched against basename or relative path (repeatable)")
+	fs.BoolVar(&cfg.GitIgnore, "gitignore", cfg.GitIgnore, "skip paths excluded by .gitignore files, .git/info/exclude and the global git excludes file")
+	fs.BoolVar(&cfg.GitTracked, "git-tracked", cfg.GitTracked, "check only files in the git index, including staged files, instead of walking --dir")
+	fs.BoolVar(&cfg.GitUntracked, "git-untracked", cfg.GitUntracked, "also check untracked files that git does not ignore (implies --git-tracked)")
+	fs.BoolVar(&cfg.FollowSymlinks, "follow-symlinks", cfg.FollowSymlinks, "descend into symlinked directories (each real directory once) and treat paths through symlinks as the same page")
 	fs.StringVar(&cfg.Format, "format", cfg.Format, "output format: text or json")
 	fs.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose, "print validation diagnostics")
 	fs.StringVar(&cfg.Unresolved, "unresolved", cfg.Unresolved, "unresolved-link mode: fail, warn, report, none")
+	fs.StringVar(&cfg.Anchors, "anchors", cfg.Anchors, "heading slug style for #fragment checks: github, gitlab, hugo, mkdocs, none")
+	fs.StringVar(&cfg.WikiLinks, "wikilinks", cfg.WikiLinks, "wikilink resolution: relative (path from the linking file) or shortest (Obsidian-style basename lookup)")
+	fs.StringVar(&cfg.Drafts, "drafts", cfg.Drafts, "front matter draft: true handling: include, exclude (drop from scan), no-links (scan but ignore outgoing links)")
+	fs.StringVar(&cfg.MDXLinks, "mdx-links", cfg.MDXLinks, "comma-separated Component:attribute pairs whose values are link targets in .mdx files")
+	fs.StringVar(&cfg.Includes, "includes", cfg.Includes, "comma-separated include directives whose targets are linked: snippets, markdown-include, comment, or a regexp with a capture group (\"none\" disables)")
+	fs.StringVar(&cfg.Hugo, "hugo", cfg.Hugo, "Hugo mode: none, refs (resolve ref/relref shortcodes), sections (refs plus _index.md linking to its section's pages)")
+	fs.BoolVar(&cfg.Jekyll, "jekyll", cfg.Jekyll, "resolve Jekyll Liquid link/post_url/include tags and permalink front matter")
+	fs.BoolVar(&cfg.MyST, "myst", cfg.MyST, "resolve MyST/Sphinx toctree directives and {doc}/{ref} roles")
+	fs.StringVar(&cfg.MkDocs, "mkdocs", cfg.MkDocs, "mkdocs.yml whose nav pages are roots; defaults --dir to its docs_dir and --root to the first nav page")
+	fs.StringVar(&cfg.Summary, "summary", cfg.Summary, "mdBook/GitBook SUMMARY.md that lists every built page; defaults --dir to its directory and --root to the file itself")
 	fs.StringVar(&cfg.GraphFormat, "graph", cfg.GraphFormat, "graph export mode: none, dot, mermaid")
 	fs.IntVar(&cfg.Workers, "workers", cfg.Workers, "max concurrent graph build workers (0 uses GOMAXPROCS)")
 	fs.IntVar(&cfg.MaxGraphNodes, "max-graph-nodes", cfg.MaxGraphNodes, "max nodes to render for graph export (0 disables limit)")
+	fs.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "abort the check after this duration, e.g. 30s or 2m (0 disables)")
 	fs.StringVar(&cfg.ConfigPath, "config", cfg.ConfigPath, "optional config file path")
 	fs.Usage = func() {
-		_, _ = fmt.Fprintln(stderr, "Usage: gorphan --root <file.md> [--dir <directory>] [options]")
+		_, _ = fmt.Fprintln(stderr, "Usage: gorphan --root <file.md>... [--dir <directory>...] [options]")
 		_, _ = fmt.Fprintln(stderr)
 		fs.PrintDefaults()
 	}
@@ -329,12 +498,24 @@ func parseArgs(args []string, stderr io.Writer) (config, error) {
 	if err := fs.Parse(args); err != nil {
 		return config{}, err
 	}
+	resolveSet := cfg.Resolve != ""
+	fs.Visit(func(f *flag.Flag) { resolveSet = resolveSet || f.Name == "resolve" })
+	if !resolveSet {
+		cfg.Resolve = strings.Join(gorphan.ResolveCandidates(scanner.NormalizeExtensions(cfg.Ext)), ",")
+	}
 	if fs.NArg() > 0 {
 		return config{}, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
 	}
 
+	if len(roots) > 0 {
+		cfg.Root, cfg.Roots = roots[0], append([]string(nil), roots[1:]...)
+	}
+	if len(dirs) > 0 {
+		cfg.Dir, cfg.Dirs = dirs[0], append([]string(nil), dirs[1:]...)
+	}
 	cfg.Ignore = append(cfg.Ignore, []string(ignores)...)
 	cfg.IgnoreCheckFiles = append(cfg.IgnoreCheckFiles, []string(ignoreCheckFiles)...)
+	cfg.Assets = append(cfg.Assets, []string(assets)...)
 	if err := validateAndNormalize(&cfg); err != nil {
 		_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
 		return config{}, err
@@ -344,11 +525,15 @@ func parseArgs(args []string, stderr io.Writer) (config, error) {
 }
 
 func validateAndNormalize(cfg *config) error {
-	if strings.TrimSpace(cfg.Root) == "" {
-		return fmt.Errorf("--root is required")
+	if strings.TrimSpace(cfg.Includes) == "none" {
+		cfg.Includes = ""
 	}
-	if strings.TrimSpace(cfg.Dir) == "" {
-		cfg.Dir = "."
+	paths, err := gorphan.Normalize(cfg.options())
+	if err != nil {
+		return err
+	}
+	if paths.Root == "" {
+		return fmt.Errorf("--root is required")
 	}
 
 	cfg.Format = strings.ToLower(strings.TrimSpace(cfg.Format))
@@ -359,6 +544,12 @@ func validateAndNormalize(cfg *config) error {
 	if cfg.Unresolved != "fail" && cfg.Unresolved != "warn" && cfg.Unresolved != "report" && cfg.Unresolved != "none" {
 		return fmt.Errorf("--unresolved must be one of: fail, warn, report, none")
 	}
+	if _, err := parser.ParseJSXLinks(pathutil.SplitList(cfg.MDXLinks)); err != nil {
+		return fmt.Errorf("--mdx-links: %w", err)
+	}
+	if _, err := parser.ParseIncludePatterns(pathutil.SplitList(cfg.Includes)); err != nil {
+		return fmt.Errorf("--includes: %w", err)
+	}
 	cfg.GraphFormat = strings.ToLower(strings.TrimSpace(cfg.GraphFormat))
 	if cfg.GraphFormat != "none" && cfg.GraphFormat != "dot" && cfg.GraphFormat != "mermaid" {
 		return fmt.Errorf("--graph must be one of: none, dot, mermaid")
@@ -369,132 +560,51 @@ func validateAndNormalize(cfg *config) error {
 	if cfg.MaxGraphNodes < 0 {
 		return fmt.Errorf("--max-graph-nodes must be >= 0")
 	}
-
-	dirAbs, err := pathutil.NormalizeAbs(cfg.Dir)
-	if err != nil {
-		return fmt.Errorf("resolve --dir: %w", err)
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("--timeout must be >= 0")
 	}
 
-	dirInfo, err := os.Stat(dirAbs)
-	if err != nil {
-		return fmt.Errorf("scan directory does not exist: %s", dirAbs)
-	}
-	if !dirInfo.IsDir() {
-		return fmt.Errorf("--dir must be a directory: %s", dirAbs)
-	}
-
-	rootAbs, err := pathutil.NormalizeAbs(cfg.Root)
-	if err != nil {
-		return fmt.Errorf("resolve --root: %w", err)
-	}
-
-	rootInfo, err := os.Stat(rootAbs)
-	if err != nil {
-		return fmt.Errorf("root file does not exist: %s", rootAbs)
-	}
-	if rootInfo.IsDir() {
-		return fmt.Errorf("--root must be a file: %s", rootAbs)
-	}
-
-	rel, err := filepath.Rel(dirAbs, rootAbs)
-	if err != nil {
-		return fmt.Errorf("verify root location: %w", err)
-	}
-	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
-		return fmt.Errorf("--root must be within --dir: root=%s dir=%s", rootAbs, dirAbs)
-	}
-
-	cfg.Dir = dirAbs
-	cfg.Root = rootAbs
-	return nil
-}
-
-func filterIgnoredCheckFiles(scanDir string, orphanFiles []string, rules []string) ([]string, error) {
-	if len(orphanFiles) == 0 || len(rules) == 0 {
-		return orphanFiles, nil
-	}
-
-	scanDirAbs, err := pathutil.NormalizeAbs(scanDir)
-	if err != nil {
-		return nil, fmt.Errorf("resolve scan dir: %w", err)
-	}
-
-	filtered := make([]string, 0, len(orphanFiles))
-	for _, orphan := range orphanFiles {
-		ignored, err := isIgnoredCheckFile(scanDirAbs, orphan, rules)
+	for _, dirAbs := range paths.Dirs {
+		dirInfo, err := os.Stat(dirAbs)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("scan directory does not exist: %s", dirAbs)
 		}
-		if ignored {
-			continue
+		if !dirInfo.IsDir() {
+			return fmt.Errorf("--dir must be a directory: %s", dirAbs)
 		}
-		filtered = append(filtered, orphan)
 	}
-	return filtered, nil
-}
 
-func isIgnoredCheckFile(scanDir, file string, rules []string) (bool, error) {
-	fileAbs, err := pathutil.NormalizeAbs(file)
-	if err != nil {
-		return false, fmt.Errorf("resolve orphan file path %q: %w", file, err)
-	}
-	rel, err := filepath.Rel(scanDir, fileAbs)
-	if err != nil {
-		return false, fmt.Errorf("resolve orphan file relative path %q: %w", fileAbs, err)
-	}
-	rel = filepath.ToSlash(filepath.Clean(rel))
-	base := filepath.Base(fileAbs)
-
-	for _, rawRule := range rules {
-		ruleRaw := strings.TrimSpace(rawRule)
-		if ruleRaw == "" {
-			continue
-		}
-		rule := normalizeIgnoreCheckRule(scanDir, ruleRaw)
-		if strings.Contains(rule, "/") {
-			if rel == rule {
-				return true, nil
-			}
-			continue
+	for _, rootAbs := range paths.Roots {
+		rootInfo, err := os.Stat(rootAbs)
+		if err != nil {
+			return fmt.Errorf("root file does not exist: %s", rootAbs)
 		}
-		if base == rule {
-			return true, nil
+		if rootInfo.IsDir() {
+			return fmt.Errorf("--root must be a file: %s", rootAbs)
 		}
-	}
-	return false, nil
-}
-
-func normalizeIgnoreCheckRule(scanDir, rule string) string {
-	if filepath.IsAbs(rule) {
-		absRule := filepath.Clean(rule)
-		if rel, err := filepath.Rel(scanDir, absRule); err == nil {
-			rel = filepath.Clean(rel)
-			if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
-				rule = rel
-			}
+		if _, ok := pathutil.ContainingDir(paths.Dirs, rootAbs); !ok {
+			return fmt.Errorf("--root must be within --dir: root=%s dir=%s", rootAbs, strings.Join(paths.Dirs, ", "))
 		}
 	}
-	return filepath.ToSlash(filepath.Clean(rule))
-}
-
-func toRelativeSlash(baseDir string, files []string) ([]string, error) {
-	baseAbs, err := pathutil.NormalizeAbs(baseDir)
-	if err != nil {
-		return nil, fmt.Errorf("resolve base directory: %w", err)
-	}
 
-	absolute := make([]string, 0, len(files))
-	for _, file := range files {
-		abs, err := pathutil.NormalizeAbs(file)
+	if strings.TrimSpace(cfg.SiteRoot) != "" {
+		info, err := os.Stat(paths.SiteRoot)
 		if err != nil {
-			return nil, fmt.Errorf("resolve file path %q: %w", file, err)
+			return fmt.Errorf("site root does not exist: %s", paths.SiteRoot)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("--site-root must be a directory: %s", paths.SiteRoot)
 		}
-		absolute = append(absolute, abs)
 	}
 
-	relative, err := pathutil.RelativeSlashMany(baseAbs, absolute)
-	if err != nil {
-		return nil, fmt.Errorf("convert orphan path to relative: %w", err)
-	}
-	return relative, nil
+	cfg.Dir, cfg.Dirs = paths.Dir, paths.Dirs
+	cfg.Root, cfg.Roots = paths.Root, paths.Roots
+	cfg.SiteRoot = paths.SiteRoot
+	cfg.Anchors = string(paths.Anchors)
+	cfg.WikiLinks = string(paths.WikiLinks)
+	cfg.Drafts = string(paths.Drafts)
+	cfg.Hugo = string(paths.Hugo)
+	cfg.MkDocs = paths.MkDocs
+	cfg.Summary = paths.Summary
+	return nil
 }
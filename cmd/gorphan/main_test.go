@@ -2,13 +2,16 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
-	"gorphan/internal/testutil"
+	"github.com/shogo-nakano-desu/gorphan"
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
 )
 
 func TestParseArgsAndValidate_Success(t *testing.T) {
@@ -78,6 +81,51 @@ func TestParseArgsAndValidate_RootOutsideDir(t *testing.T) {
 	}
 }
 
+func TestRun_MultipleDirsAndRoots(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	handbook := filepath.Join(dir, "handbook")
+	testutil.MustWrite(t, filepath.Join(docs, "index.md"), "[guide](../handbook/guide.md)")
+	testutil.MustWrite(t, filepath.Join(handbook, "guide.md"), "# guide\n\n[gone](missing.md)")
+	testutil.MustWrite(t, filepath.Join(handbook, "README.md"), "# handbook")
+	testutil.MustWrite(t, filepath.Join(handbook, "drafts", "old.md"), "# old")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	args := []string{"--root", filepath.Join(docs, "index.md"), "--root", filepath.Join(handbook, "README.md"), "--dir", docs, "--dir", handbook, "--format", "json", "--unresolved", "report", "--graph", "mermaid"}
+	if code := run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d; stderr=%s", code, stderr.String())
+	}
+	var out struct {
+		Orphans  []string `json:"orphans"`
+		Roots    []string `json:"roots"`
+		Dirs     []string `json:"dirs"`
+		Graph    string   `json:"graph"`
+		Warnings []struct {
+			File    string `json:"file"`
+			Target  string `json:"target"`
+			Message string `json:"message"`
+		} `json:"warnings"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("decode json failed: %v\n%s", err, stdout.String())
+	}
+	if want := []string{"drafts/old.md"}; !reflect.DeepEqual(out.Orphans, want) {
+		t.Fatalf("orphans = %v, want %v", out.Orphans, want)
+	}
+	if len(out.Roots) != 2 || len(out.Dirs) != 2 {
+		t.Fatalf("expected both roots and dirs in report, got roots=%v dirs=%v", out.Roots, out.Dirs)
+	}
+	// Warnings and graph nodes are relative to the dir holding each file,
+	// like orphans.
+	if len(out.Warnings) != 1 || out.Warnings[0].File != "guide.md" || out.Warnings[0].Target != "missing.md" || !strings.Contains(out.Warnings[0].Message, "guide.md:3:1") {
+		t.Fatalf("unexpected warnings: %+v", out.Warnings)
+	}
+	if !strings.Contains(out.Graph, `"index.md" --> "guide.md"`) {
+		t.Fatalf("graph labels not relative to their dirs:\n%s", out.Graph)
+	}
+}
+
 func TestParseArgs_WorkersFlag(t *testing.T) {
 	dir := t.TempDir()
 	root := filepath.Join(dir, "index.md")
@@ -114,6 +162,55 @@ func TestParseArgs_MaxGraphNodesRejectsNegative(t *testing.T) {
 	}
 }
 
+func TestParseArgs_TimeoutFlag(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "# root")
+
+	cfg, err := parseArgs([]string{"--root", root, "--dir", dir, "--timeout", "2m"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if cfg.Timeout != 2*time.Minute {
+		t.Fatalf("unexpected timeout value: %s", cfg.Timeout)
+	}
+
+	_, err = parseArgs([]string{"--root", root, "--dir", dir, "--timeout", "-1s"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "--timeout must be >= 0") {
+		t.Fatalf("expected timeout validation error, got: %v", err)
+	}
+}
+
+func TestRun_TimeoutExceeded(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "# root")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", dir, "--timeout", "1ns"}, &stdout, &stderr)
+	if code != 2 {
+		t.Fatalf("expected exit code 2, got %d\nstderr:\n%s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "error: timed out after 1ns") {
+		t.Fatalf("unexpected stderr:\n%s", stderr.String())
+	}
+}
+
+func TestProgressPrinter(t *testing.T) {
+	if p := newProgressPrinter(&bytes.Buffer{}); p != nil {
+		t.Fatal("expected no progress printer for a non-terminal writer")
+	}
+
+	var out bytes.Buffer
+	p := &progressPrinter{w: &out}
+	p.update(gorphan.Progress{Scanned: 3})
+	p.update(gorphan.Progress{Scanned: 3, Parsed: 1, Total: 3})
+	p.clear()
+	if got, want := out.String(), "\r\033[Kscanned 3 files\r\033[K"; got != want {
+		t.Fatalf("unexpected progress output: %q, want %q", got, want)
+	}
+}
+
 func TestRun_ValidInput(t *testing.T) {
 	dir := t.TempDir()
 	docs := filepath.Join(dir, "docs")
@@ -169,6 +266,26 @@ func TestRun_ValidInputWithoutDir(t *testing.T) {
 	}
 }
 
+func TestRun_GitIgnoreSkipsIgnoredOrphans(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "# root")
+	testutil.MustWrite(t, filepath.Join(dir, ".gitignore"), "node_modules/\n")
+	testutil.MustWrite(t, filepath.Join(dir, "node_modules", "pkg", "README.md"), "# vendored")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if code := run([]string{"--root", root, "--dir", dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected vendored orphan without --gitignore, got exit code %d; stderr=%s", code, stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--root", root, "--dir", dir, "--gitignore"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0 with --gitignore, got %d; stdout=%s stderr=%s", code, stdout.String(), stderr.String())
+	}
+}
+
 func TestRun_InvalidFormat(t *testing.T) {
 	dir := t.TempDir()
 	root := filepath.Join(dir, "index.md")
@@ -259,8 +376,8 @@ func TestRun_DefaultUnresolvedFailMode(t *testing.T) {
 	if code != 1 {
 		t.Fatalf("expected exit code 1 for unresolved links in default fail mode, got %d", code)
 	}
-	if !strings.Contains(stderr.String(), "error: unresolved local markdown link:") {
-		t.Fatalf("expected unresolved error, got: %s", stderr.String())
+	if !strings.Contains(stderr.String(), "error: unresolved local markdown link: index.md:1:1 -> ./missing.md") {
+		t.Fatalf("expected unresolved error with location, got: %s", stderr.String())
 	}
 }
 
@@ -284,6 +401,26 @@ func TestRun_UnresolvedReportMode(t *testing.T) {
 	}
 }
 
+func TestRun_UnresolvedReportModeJSON(t *testing.T) {
+	dir := t.TempDir()
+	docs := filepath.Join(dir, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "# Root\n\nSee [missing](./sub/missing.md).\n")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := run([]string{"--root", root, "--dir", docs, "--unresolved", "report", "--format", "json"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d; stderr=%s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{`"file": "index.md"`, `"line": 3`, `"column": 5`, `"destination": "./sub/missing.md"`, `"target": "sub/missing.md"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in json warnings, got: %s", want, out)
+		}
+	}
+}
+
 func TestRun_UnresolvedWarnMode(t *testing.T) {
 	dir := t.TempDir()
 	docs := filepath.Join(dir, "docs")
@@ -420,3 +557,40 @@ func TestParseArgs_ConfigAndFlagOverride(t *testing.T) {
 		t.Fatalf("unexpected merged ignore list: %#v", cfg.Ignore)
 	}
 }
+
+func TestParseArgs_SiteRootDefaults(t *testing.T) {
+	repo := t.TempDir()
+	docs := filepath.Join(repo, "docs")
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "# root")
+
+	cfg, err := parseArgs([]string{"--root", root, "--dir", docs}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if cfg.SiteRoot != cfg.Dir {
+		t.Fatalf("expected site root to default to --dir without a repository, got %q", cfg.SiteRoot)
+	}
+
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	cfg, err = parseArgs([]string{"--root", root, "--dir", docs}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if cfg.SiteRoot != filepath.Clean(repo) {
+		t.Fatalf("expected site root to default to repository root %q, got %q", repo, cfg.SiteRoot)
+	}
+}
+
+func TestParseArgs_SiteRootMustBeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	testutil.MustWrite(t, root, "# root")
+
+	_, err := parseArgs([]string{"--root", root, "--dir", dir, "--site-root", root}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "--site-root must be a directory") {
+		t.Fatalf("expected site-root validation error, got: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/shogo-nakano-desu/gorphan"
+)
+
+const progressInterval = 100 * time.Millisecond
+
+// progressPrinter redraws a single status line on a terminal. Updates are
+// throttled so large trees do not flood the terminal.
+type progressPrinter struct {
+	w       io.Writer
+	last    time.Time
+	printed bool
+}
+
+// newProgressPrinter returns nil unless w is a terminal, so redirected or
+// captured stderr never sees carriage returns.
+func newProgressPrinter(w io.Writer) *progressPrinter {
+	f, ok := w.(*os.File)
+	if !ok {
+		return nil
+	}
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressPrinter{w: w}
+}
+
+func (p *progressPrinter) update(progress gorphan.Progress) {
+	now := time.Now()
+	if now.Sub(p.last) < progressInterval {
+		return
+	}
+	p.last = now
+	p.printed = true
+	if progress.Total == 0 {
+		_, _ = fmt.Fprintf(p.w, "\r\033[Kscanned %d files", progress.Scanned)
+		return
+	}
+	_, _ = fmt.Fprintf(p.w, "\r\033[Kscanned %d files, parsed %d/%d", progress.Scanned, progress.Parsed, progress.Total)
+}
+
+// clear erases the status line before results are printed.
+func (p *progressPrinter) clear() {
+	if p.printed {
+		_, _ = fmt.Fprint(p.w, "\r\033[K")
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"strings"
 	"testing"
 
-	"gorphan/internal/testutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
 )
 
 func TestCLIEndToEnd_NoOrphans(t *testing.T) {
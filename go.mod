@@ -1,3 +1,3 @@
-module gorphan
+module github.com/shogo-nakano-desu/gorphan
 
 go 1.25.0
@@ -0,0 +1,200 @@
+// Package gorphan finds markdown pages that cannot be reached by following
+// links from a root page. Check runs the whole pipeline the gorphan CLI uses:
+// scan the directory, parse every page, build the link graph and analyze
+// reachability.
+package gorphan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/graph"
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/scanner"
+	"github.com/shogo-nakano-desu/gorphan/internal/slug"
+)
+
+type (
+	Graph        = graph.Graph
+	Edge         = graph.Edge
+	Analysis     = graph.Analysis
+	Warning      = graph.Warning
+	WarningKind  = graph.WarningKind
+	Link         = parser.Link
+	LinkKind     = parser.LinkKind
+	AnchorStyle  = slug.Style
+	WikiLinkMode = graph.WikiLinkMode
+	DraftMode    = graph.DraftMode
+	HugoMode     = graph.HugoMode
+)
+
+const (
+	AnchorsGitHub AnchorStyle = slug.GitHub
+	AnchorsGitLab AnchorStyle = slug.GitLab
+	AnchorsHugo   AnchorStyle = slug.Hugo
+	AnchorsMkDocs AnchorStyle = slug.MkDocs
+	AnchorsNone   AnchorStyle = "none"
+
+	WikiLinkRelative = graph.WikiLinkRelative
+	WikiLinkShortest = graph.WikiLinkShortest
+
+	DraftsInclude = graph.DraftsInclude
+	DraftsExclude = graph.DraftsExclude
+	DraftsNoLinks = graph.DraftsNoLinks
+
+	HugoNone     = graph.HugoNone
+	HugoRefs     = graph.HugoRefs
+	HugoSections = graph.HugoSections
+
+	WarningUnresolvedLink  = graph.WarningUnresolvedLink
+	WarningUnresolvedAsset = graph.WarningUnresolvedAsset
+	WarningBrokenFragment  = graph.WarningBrokenFragment
+	WarningAmbiguousWiki   = graph.WarningAmbiguousWiki
+	WarningAmbiguousRef    = graph.WarningAmbiguousRef
+	WarningUnresolvedNav   = graph.WarningUnresolvedNav
+	WarningDraftNav        = graph.WarningDraftNav
+)
+
+// Result is the outcome of a Check. Options holds the normalized options the
+// check ran with. Analysis orphans already leave out IgnoreCheckFiles.
+type Result struct {
+	Options  Options
+	Files    []string
+	Assets   []string
+	Graph    *Graph
+	Analysis *Analysis
+	Warnings []Warning
+}
+
+// Progress reports how far a Check has come. Scanned counts the pages and
+// assets found so far; Parsed and Total stay zero until parsing starts.
+type Progress struct {
+	Scanned int
+	Parsed  int
+	Total   int
+}
+
+// Check scans opts.Dir and opts.Dirs, builds the link graph and reports the
+// pages and assets that are not reachable from opts.Root or opts.Roots. It
+// returns ctx's error once ctx is done.
+func Check(ctx context.Context, opts Options) (*Result, error) {
+	opts, err := Normalize(opts)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(opts.Root) == "" {
+		return nil, fmt.Errorf("root is required")
+	}
+	extractors, err := opts.extractors()
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := scanDirs(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scanned := len(inventory.Files) + len(inventory.Assets)
+	linkGraph, err := graph.Build(ctx, graph.Options{
+		Root:           opts.Root,
+		Roots:          opts.Roots[1:],
+		ScanDir:        opts.Dir,
+		ScanDirs:       opts.Dirs[1:],
+		FS:             opts.FS,
+		SiteRoot:       opts.SiteRoot,
+		Files:          inventory.Files,
+		Extensions:     opts.Extensions,
+		Assets:         inventory.Assets,
+		AssetPatterns:  opts.Assets,
+		Candidates:     opts.Resolve,
+		AnchorStyle:    opts.anchorStyle(),
+		WikiLinks:      opts.WikiLinks,
+		Drafts:         opts.Drafts,
+		Hugo:           opts.Hugo,
+		Jekyll:         opts.Jekyll,
+		MyST:           opts.MyST,
+		Nav:            opts.navPages,
+		NavOnly:        opts.Summary != "",
+		Extractors:     extractors,
+		MaxWorkers:     opts.Workers,
+		FollowSymlinks: opts.FollowSymlinks && opts.FS == nil,
+		Progress:       opts.parseProgress(scanned),
+	})
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := graph.Analyze(linkGraph, opts.Dirs, inventory.Files)
+	if err != nil {
+		return nil, err
+	}
+	if err := dropIgnoredCheckFiles(opts.Dirs, analysis, opts.IgnoreCheckFiles); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Options:  opts,
+		Files:    inventory.Files,
+		Assets:   inventory.Assets,
+		Graph:    linkGraph,
+		Analysis: analysis,
+		Warnings: linkGraph.Warnings,
+	}, nil
+}
+
+// scanDirs scans every directory in opts.Dirs and merges the results. Files
+// found under more than one directory are listed once.
+func scanDirs(ctx context.Context, opts Options) (scanner.Inventory, error) {
+	merged := scanner.Inventory{Files: make([]string, 0), Assets: make([]string, 0)}
+	seen := make(map[string]struct{})
+	for _, dir := range opts.Dirs {
+		offset := len(merged.Files) + len(merged.Assets)
+		inventory, err := scanner.ScanInventory(ctx, scanner.Options{
+			Dir:            dir,
+			FS:             opts.FS,
+			Extensions:     opts.Extensions,
+			Ignore:         opts.Ignore,
+			Assets:         opts.Assets,
+			GitIgnore:      opts.GitIgnore,
+			GitTracked:     opts.GitTracked,
+			GitUntracked:   opts.GitUntracked,
+			FollowSymlinks: opts.FollowSymlinks,
+			Progress:       opts.scanProgress(offset),
+		})
+		if err != nil {
+			return scanner.Inventory{}, err
+		}
+		merged.Files = appendUnseen(merged.Files, inventory.Files, seen)
+		merged.Assets = appendUnseen(merged.Assets, inventory.Assets, seen)
+	}
+	if len(opts.Dirs) > 1 {
+		sort.Strings(merged.Files)
+		sort.Strings(merged.Assets)
+	}
+	return merged, nil
+}
+
+func appendUnseen(dst, paths []string, seen map[string]struct{}) []string {
+	for _, path := range paths {
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		dst = append(dst, path)
+	}
+	return dst
+}
+
+// ExportDOT renders the graph in Graphviz DOT syntax with each path relative
+// to the deepest of dirs holding it, as orphans are reported.
+func ExportDOT(g *Graph, dirs ...string) (string, error) {
+	return graph.ExportDOT(g, dirs...)
+}
+
+// ExportMermaid renders the graph as a Mermaid flowchart with each path
+// relative to the deepest of dirs holding it.
+func ExportMermaid(g *Graph, dirs ...string) (string, error) {
+	return graph.ExportMermaid(g, dirs...)
+}
@@ -0,0 +1,217 @@
+package gorphan
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
+)
+
+func TestCheck_ReportsOrphansAndWarnings(t *testing.T) {
+	docs := t.TempDir()
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "[child](child.md)\n[missing](missing.md)\n")
+	testutil.MustWrite(t, filepath.Join(docs, "child.md"), "# child\n")
+	testutil.MustWrite(t, filepath.Join(docs, "orphan.md"), "# orphan\n")
+	testutil.MustWrite(t, filepath.Join(docs, "notes", "skip.md"), "# skip\n")
+
+	result, err := Check(context.Background(), Options{
+		Root:             root,
+		Dir:              docs,
+		IgnoreCheckFiles: []string{"notes/skip.md"},
+	})
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if want := []string{"orphan.md"}; !reflect.DeepEqual(result.Analysis.OrphansRelative, want) {
+		t.Fatalf("orphans = %v, want %v", result.Analysis.OrphansRelative, want)
+	}
+	if len(result.Files) != 4 {
+		t.Fatalf("files = %v, want 4 entries", result.Files)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Kind != WarningUnresolvedLink {
+		t.Fatalf("warnings = %+v, want one unresolved link", result.Warnings)
+	}
+}
+
+func TestCheck_FS(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		"index.md":  {Data: []byte("[child](child.md)\n")},
+		"child.md":  {Data: []byte("# child\n")},
+		"orphan.md": {Data: []byte("# orphan\n")},
+	}
+
+	result, err := Check(context.Background(), Options{Root: filepath.Join(dir, "index.md"), Dir: dir, FS: fsys})
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if want := []string{"orphan.md"}; !reflect.DeepEqual(result.Analysis.OrphansRelative, want) {
+		t.Fatalf("orphans = %v, want %v", result.Analysis.OrphansRelative, want)
+	}
+	if result.Options.SiteRoot != dir {
+		t.Fatalf("site root = %q, want %q", result.Options.SiteRoot, dir)
+	}
+}
+
+func TestCheck_ResolvesExtensionlessLinksWithEveryExtension(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		"index.md":            {Data: []byte("[setup](./setup) [api](./api/)\n")},
+		"setup.markdown":      {Data: []byte("# setup\n")},
+		"api/README.markdown": {Data: []byte("# api\n")},
+	}
+
+	result, err := Check(context.Background(), Options{Root: filepath.Join(dir, "index.md"), Dir: dir, FS: fsys})
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if len(result.Analysis.OrphansRelative) != 0 || len(result.Warnings) != 0 {
+		t.Fatalf("orphans = %v, warnings = %+v, want none", result.Analysis.OrphansRelative, result.Warnings)
+	}
+}
+
+func TestCheck_MultipleDirsAndRoots(t *testing.T) {
+	repo := t.TempDir()
+	docs := filepath.Join(repo, "docs")
+	handbook := filepath.Join(repo, "handbook")
+	services := filepath.Join(repo, "services")
+	testutil.MustWrite(t, filepath.Join(repo, "README.md"), "# repo\n")
+	testutil.MustWrite(t, filepath.Join(docs, "index.md"), "[guide](../handbook/guide.md)\n[readme](../README.md)\n")
+	testutil.MustWrite(t, filepath.Join(handbook, "guide.md"), "# guide\n")
+	testutil.MustWrite(t, filepath.Join(handbook, "old.md"), "# old\n")
+	testutil.MustWrite(t, filepath.Join(services, "api", "docs", "index.md"), "[setup](setup.md)\n")
+	testutil.MustWrite(t, filepath.Join(services, "api", "docs", "setup.md"), "# setup\n")
+	testutil.MustWrite(t, filepath.Join(services, "api", "docs", "orphan.md"), "# orphan\n")
+	testutil.MustWrite(t, filepath.Join(services, "web", "docs", "index.md"), "# web\n")
+
+	result, err := Check(context.Background(), Options{
+		Root:  filepath.Join(docs, "index.md"),
+		Roots: []string{filepath.Join(services, "*", "docs", "index.md")},
+		Dir:   docs,
+		Dirs:  []string{handbook, filepath.Join(services, "api", "docs"), filepath.Join(services, "web", "docs")},
+	})
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if want := []string{"old.md", "orphan.md"}; !reflect.DeepEqual(result.Analysis.OrphansRelative, want) {
+		t.Fatalf("orphans = %v, want %v", result.Analysis.OrphansRelative, want)
+	}
+	if len(result.Files) != 7 {
+		t.Fatalf("files = %v, want 7 entries", result.Files)
+	}
+	if len(result.Warnings) != 0 {
+		t.Fatalf("warnings = %+v, want none", result.Warnings)
+	}
+	wantRoots := []string{
+		filepath.Join(docs, "index.md"),
+		filepath.Join(services, "api", "docs", "index.md"),
+		filepath.Join(services, "web", "docs", "index.md"),
+	}
+	if !reflect.DeepEqual(result.Options.Roots, wantRoots) {
+		t.Fatalf("roots = %v, want %v", result.Options.Roots, wantRoots)
+	}
+
+	_, err = Check(context.Background(), Options{Root: filepath.Join(repo, "missing", "*.md"), Dir: docs})
+	if err == nil {
+		t.Fatalf("expected error for a root pattern without matches")
+	}
+}
+
+func TestCheck_Progress(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		"index.md": {Data: []byte("[child](child.md)\n")},
+		"child.md": {Data: []byte("# child\n")},
+	}
+
+	var last Progress
+	_, err := Check(context.Background(), Options{
+		Root:     filepath.Join(dir, "index.md"),
+		Dir:      dir,
+		FS:       fsys,
+		Progress: func(p Progress) { last = p },
+	})
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if want := (Progress{Scanned: 2, Parsed: 2, Total: 2}); last != want {
+		t.Fatalf("last progress = %+v, want %+v", last, want)
+	}
+}
+
+func TestCheck_RequiresRoot(t *testing.T) {
+	if _, err := Check(context.Background(), Options{Dir: t.TempDir()}); err == nil {
+		t.Fatal("expected error without root")
+	}
+}
+
+func TestCheck_CanceledContext(t *testing.T) {
+	docs := t.TempDir()
+	root := filepath.Join(docs, "index.md")
+	testutil.MustWrite(t, root, "# root\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Check(ctx, Options{Root: root, Dir: docs}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNormalize_Defaults(t *testing.T) {
+	docs := t.TempDir()
+	opts, err := Normalize(Options{Root: filepath.Join(docs, "index.md"), Dir: docs})
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if !reflect.DeepEqual(opts.Extensions, DefaultExtensions) || !reflect.DeepEqual(opts.Resolve, DefaultResolve) {
+		t.Fatalf("unexpected defaults: ext=%v resolve=%v", opts.Extensions, opts.Resolve)
+	}
+	if opts.Anchors != AnchorsNone || opts.WikiLinks != WikiLinkRelative || opts.Drafts != DraftsInclude || opts.Hugo != HugoNone {
+		t.Fatalf("unexpected modes: %+v", opts)
+	}
+	opts.Extensions[0], opts.Resolve[0] = ".txt", ".txt"
+	if DefaultExtensions[0] != ".md" || DefaultResolve[0] != ".md" {
+		t.Fatalf("editing normalized options changed the defaults: ext=%v resolve=%v", DefaultExtensions, DefaultResolve)
+	}
+	if opts.SiteRoot == "" || !filepath.IsAbs(opts.SiteRoot) {
+		t.Fatalf("site root = %q, want absolute path", opts.SiteRoot)
+	}
+
+	empty, err := Normalize(Options{Root: filepath.Join(docs, "index.md"), Dir: docs, Includes: []string{}})
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if len(empty.Includes) != 0 {
+		t.Fatalf("includes = %v, want none", empty.Includes)
+	}
+}
+
+func TestNormalize_Modes(t *testing.T) {
+	docs := t.TempDir()
+	opts, err := Normalize(Options{Root: filepath.Join(docs, "index.md"), Dir: docs, Anchors: " GitHub ", WikiLinks: "Shortest", Drafts: "EXCLUDE", Hugo: "refs"})
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if opts.Anchors != AnchorsGitHub || opts.WikiLinks != WikiLinkShortest || opts.Drafts != DraftsExclude || opts.Hugo != HugoRefs {
+		t.Fatalf("unexpected modes: %+v", opts)
+	}
+
+	for _, bad := range []Options{{Anchors: "kramdown"}, {WikiLinks: "nearest"}, {Drafts: "skip"}, {Hugo: "all"}} {
+		bad.Root, bad.Dir = filepath.Join(docs, "index.md"), docs
+		if _, err := Normalize(bad); err == nil || !strings.Contains(err.Error(), "must be one of") {
+			t.Fatalf("Normalize(%+v) error = %v, want invalid mode", bad, err)
+		}
+	}
+}
+
+func TestNormalize_RejectsMkDocsWithSummary(t *testing.T) {
+	if _, err := Normalize(Options{MkDocs: "mkdocs.yml", Summary: "SUMMARY.md"}); err == nil {
+		t.Fatal("expected error when combining mkdocs and summary")
+	}
+}
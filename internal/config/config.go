@@ -7,20 +7,41 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const DefaultConfigPath = ".gorphan.yaml"
 
 type FileConfig struct {
 	Root             string
+	Roots            []string
 	Dir              string
+	Dirs             []string
+	SiteRoot         string
 	Ext              string
+	Resolve          string
 	Ignore           []string
 	IgnoreCheckFiles []string
+	Assets           []string
+	GitIgnore        *bool
+	GitTracked       *bool
+	GitUntracked     *bool
+	FollowSymlinks   *bool
 	Format           string
 	Verbose          *bool
 	Unresolved       string
+	Anchors          string
+	WikiLinks        string
+	Drafts           string
+	MDXLinks         string
+	Includes         string
+	Hugo             string
+	Jekyll           *bool
+	MyST             *bool
+	MkDocs           string
+	Summary          string
 	Graph            string
+	Timeout          time.Duration
 }
 
 type yamlToken struct {
@@ -32,6 +53,9 @@ type yamlToken struct {
 type yamlParser struct {
 	cfg         FileConfig
 	currentList string
+	// scalarKey is a root or dir key given a single value; list items may
+	// not follow it.
+	scalarKey string
 }
 
 func FindConfigArg(args []string) (path string, explicit bool, err error) {
@@ -98,7 +122,8 @@ func tokenizeYAML(content string) ([]yamlToken, error) {
 		}
 
 		if strings.HasPrefix(line, "- ") {
-			tokens = append(tokens, yamlToken{isList: true, value: strings.TrimSpace(strings.TrimPrefix(line, "- "))})
+			value := strings.TrimSpace(strings.TrimPrefix(line, "- "))
+			tokens = append(tokens, yamlToken{isList: true, value: unquote(value)})
 			continue
 		}
 
@@ -107,8 +132,7 @@ func tokenizeYAML(content string) ([]yamlToken, error) {
 			continue
 		}
 		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		value = strings.Trim(value, `"'`)
+		value := unquote(strings.TrimSpace(parts[1]))
 		tokens = append(tokens, yamlToken{key: key, value: value})
 	}
 	if err := scanner.Err(); err != nil {
@@ -117,19 +141,34 @@ func tokenizeYAML(content string) ([]yamlToken, error) {
 	return tokens, nil
 }
 
+// unquote removes one pair of matching surrounding quotes. Unbalanced
+// quotes are kept as written.
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
 func (p *yamlParser) apply(token yamlToken) error {
 	if token.isList {
 		return p.applyListItem(token.value)
 	}
-	p.currentList = ""
+	p.currentList, p.scalarKey = "", ""
 
 	switch token.key {
 	case "root":
+		p.startList("root", token.value)
 		p.cfg.Root = token.value
 	case "dir":
+		p.startList("dir", token.value)
 		p.cfg.Dir = token.value
+	case "site-root":
+		p.cfg.SiteRoot = token.value
 	case "ext":
 		p.cfg.Ext = token.value
+	case "resolve":
+		p.cfg.Resolve = token.value
 	case "ignore":
 		p.currentList = "ignore"
 		if token.value != "" {
@@ -140,6 +179,47 @@ func (p *yamlParser) apply(token yamlToken) error {
 		if token.value != "" {
 			p.cfg.IgnoreCheckFiles = append(p.cfg.IgnoreCheckFiles, token.value)
 		}
+	case "assets":
+		p.currentList = "assets"
+		if token.value != "" {
+			p.cfg.Assets = append(p.cfg.Assets, token.value)
+		}
+	case "gitignore":
+		if token.value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(token.value)
+		if err != nil {
+			return fmt.Errorf("invalid gitignore value: %s", token.value)
+		}
+		p.cfg.GitIgnore = &b
+	case "git-tracked":
+		if token.value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(token.value)
+		if err != nil {
+			return fmt.Errorf("invalid git-tracked value: %s", token.value)
+		}
+		p.cfg.GitTracked = &b
+	case "git-untracked":
+		if token.value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(token.value)
+		if err != nil {
+			return fmt.Errorf("invalid git-untracked value: %s", token.value)
+		}
+		p.cfg.GitUntracked = &b
+	case "follow-symlinks":
+		if token.value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(token.value)
+		if err != nil {
+			return fmt.Errorf("invalid follow-symlinks value: %s", token.value)
+		}
+		p.cfg.FollowSymlinks = &b
 	case "format":
 		p.cfg.Format = token.value
 	case "verbose":
@@ -153,19 +233,81 @@ func (p *yamlParser) apply(token yamlToken) error {
 		p.cfg.Verbose = &b
 	case "unresolved":
 		p.cfg.Unresolved = token.value
+	case "anchors":
+		p.cfg.Anchors = token.value
+	case "wikilinks":
+		p.cfg.WikiLinks = token.value
+	case "drafts":
+		p.cfg.Drafts = token.value
+	case "mdx-links":
+		p.cfg.MDXLinks = token.value
+	case "includes":
+		p.cfg.Includes = token.value
+	case "hugo":
+		p.cfg.Hugo = token.value
+	case "jekyll":
+		if token.value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(token.value)
+		if err != nil {
+			return fmt.Errorf("invalid jekyll value: %s", token.value)
+		}
+		p.cfg.Jekyll = &b
+	case "myst":
+		if token.value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(token.value)
+		if err != nil {
+			return fmt.Errorf("invalid myst value: %s", token.value)
+		}
+		p.cfg.MyST = &b
+	case "mkdocs":
+		p.cfg.MkDocs = token.value
+	case "summary":
+		p.cfg.Summary = token.value
 	case "graph":
 		p.cfg.Graph = token.value
+	case "timeout":
+		if token.value == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(token.value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid timeout value: %s", token.value)
+		}
+		p.cfg.Timeout = d
 	}
 
 	return nil
 }
 
+// startList makes key the current list when it has no value, so the list
+// items below it belong to it.
+func (p *yamlParser) startList(key, value string) {
+	if value == "" {
+		p.currentList = key
+		return
+	}
+	p.scalarKey = key
+}
+
 func (p *yamlParser) applyListItem(item string) error {
+	if p.scalarKey != "" {
+		return fmt.Errorf("%s has a value, so list item %q cannot follow it", p.scalarKey, item)
+	}
 	switch p.currentList {
+	case "root":
+		p.cfg.Roots = append(p.cfg.Roots, item)
+	case "dir":
+		p.cfg.Dirs = append(p.cfg.Dirs, item)
 	case "ignore":
 		p.cfg.Ignore = append(p.cfg.Ignore, item)
 	case "ignore-check-files":
 		p.cfg.IgnoreCheckFiles = append(p.cfg.IgnoreCheckFiles, item)
+	case "assets":
+		p.cfg.Assets = append(p.cfg.Assets, item)
 	default:
 		// Keep backward-compatible behavior: list items outside known list contexts are ignored.
 	}
@@ -4,7 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFindConfigArg(t *testing.T) {
@@ -23,17 +25,37 @@ func TestLoadAndParseYAML(t *testing.T) {
 	content := `
 root: docs/index.md
 dir: docs
+site-root: .
 ext: .md,.markdown
+resolve: .md,/README.md
 ignore:
   - drafts
   - archive/*
 ignore-check-files:
   - docs/private.md
   - notes.md
+assets:
+  - "*.png"
+  - img/*.svg
+gitignore: true
+git-tracked: true
+git-untracked: false
+follow-symlinks: true
 format: json
 verbose: true
 unresolved: report
+anchors: mkdocs
+wikilinks: shortest
+drafts: exclude
+mdx-links: Link:to,Card:href
+includes: snippets,comment
+hugo: sections
+jekyll: true
+myst: true
+mkdocs: site/mkdocs.yml
+summary: book/SUMMARY.md
 graph: mermaid
+timeout: 90s
 `
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatalf("write config failed: %v", err)
@@ -46,7 +68,7 @@ graph: mermaid
 	if !found {
 		t.Fatalf("expected config to be found")
 	}
-	if cfg.Root != "docs/index.md" || cfg.Dir != "docs" || cfg.Format != "json" {
+	if cfg.Root != "docs/index.md" || cfg.Dir != "docs" || cfg.SiteRoot != "." || cfg.Resolve != ".md,/README.md" || cfg.Anchors != "mkdocs" || cfg.WikiLinks != "shortest" || cfg.Drafts != "exclude" || cfg.MDXLinks != "Link:to,Card:href" || cfg.Includes != "snippets,comment" || cfg.Hugo != "sections" || cfg.Jekyll == nil || !*cfg.Jekyll || cfg.MyST == nil || !*cfg.MyST || cfg.MkDocs != "site/mkdocs.yml" || cfg.Summary != "book/SUMMARY.md" || cfg.Format != "json" {
 		t.Fatalf("unexpected scalar values: %#v", cfg)
 	}
 	if !reflect.DeepEqual(cfg.Ignore, []string{"drafts", "archive/*"}) {
@@ -55,9 +77,96 @@ graph: mermaid
 	if !reflect.DeepEqual(cfg.IgnoreCheckFiles, []string{"docs/private.md", "notes.md"}) {
 		t.Fatalf("unexpected ignore-check-files list: %#v", cfg.IgnoreCheckFiles)
 	}
+	if !reflect.DeepEqual(cfg.Assets, []string{"*.png", "img/*.svg"}) {
+		t.Fatalf("unexpected assets list: %#v", cfg.Assets)
+	}
 	if cfg.Verbose == nil || *cfg.Verbose != true {
 		t.Fatalf("expected verbose=true, got %#v", cfg.Verbose)
 	}
+	if cfg.GitIgnore == nil || !*cfg.GitIgnore {
+		t.Fatalf("expected gitignore=true, got %#v", cfg.GitIgnore)
+	}
+	if cfg.GitTracked == nil || !*cfg.GitTracked {
+		t.Fatalf("expected git-tracked=true, got %#v", cfg.GitTracked)
+	}
+	if cfg.GitUntracked == nil || *cfg.GitUntracked {
+		t.Fatalf("expected git-untracked=false, got %#v", cfg.GitUntracked)
+	}
+	if cfg.FollowSymlinks == nil || !*cfg.FollowSymlinks {
+		t.Fatalf("expected follow-symlinks=true, got %#v", cfg.FollowSymlinks)
+	}
+	if cfg.Timeout != 90*time.Second {
+		t.Fatalf("expected timeout=90s, got %v", cfg.Timeout)
+	}
+}
+
+func TestLoadRootAndDirLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gorphan.yaml")
+	content := `root:
+  - docs/index.md
+  - services/*/docs/index.md
+dir:
+  - docs
+  - services
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+
+	cfg, _, err := Load(path, true)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.Root != "" || !reflect.DeepEqual(cfg.Roots, []string{"docs/index.md", "services/*/docs/index.md"}) {
+		t.Fatalf("unexpected roots: root=%q roots=%#v", cfg.Root, cfg.Roots)
+	}
+	if cfg.Dir != "" || !reflect.DeepEqual(cfg.Dirs, []string{"docs", "services"}) {
+		t.Fatalf("unexpected dirs: dir=%q dirs=%#v", cfg.Dir, cfg.Dirs)
+	}
+}
+
+func TestLoadRejectsListAfterScalarRoot(t *testing.T) {
+	for _, key := range []string{"root", "dir"} {
+		path := filepath.Join(t.TempDir(), ".gorphan.yaml")
+		content := key + ": docs/index.md\n  - services/index.md\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write config failed: %v", err)
+		}
+		if _, _, err := Load(path, true); err == nil || !strings.Contains(err.Error(), "cannot follow") {
+			t.Fatalf("%s: expected error for list item after scalar value, got %v", key, err)
+		}
+	}
+}
+
+func TestLoadQuotedValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gorphan.yaml")
+	content := `root: "docs/index.md"
+ext: '.md'
+ignore:
+  - "*.tmp"
+  - 'drafts/'
+  - 'unbalanced
+  - plain"
+ignore-check-files:
+  - "it's.md"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+
+	cfg, _, err := Load(path, true)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.Root != "docs/index.md" || cfg.Ext != ".md" {
+		t.Fatalf("expected quoted scalars to be unquoted, got root=%q ext=%q", cfg.Root, cfg.Ext)
+	}
+	if want := []string{"*.tmp", "drafts/", "'unbalanced", `plain"`}; !reflect.DeepEqual(cfg.Ignore, want) {
+		t.Fatalf("unexpected ignore rules\nwant: %#v\n got: %#v", want, cfg.Ignore)
+	}
+	if want := []string{"it's.md"}; !reflect.DeepEqual(cfg.IgnoreCheckFiles, want) {
+		t.Fatalf("unexpected ignore-check-files\nwant: %#v\n got: %#v", want, cfg.IgnoreCheckFiles)
+	}
 }
 
 func TestLoadMissingOptional(t *testing.T) {
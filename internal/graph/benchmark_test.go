@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,7 +20,7 @@ func BenchmarkBuildLargeGraph(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		g, err := Build(opts)
+		g, err := Build(context.Background(), opts)
 		if err != nil {
 			b.Fatalf("build failed: %v", err)
 		}
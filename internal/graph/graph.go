@@ -1,37 +1,156 @@
 package graph
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
 
-	"gorphan/internal/parser"
-	"gorphan/internal/pathutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/nav"
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/slug"
 )
 
+// Options configures Build. Pages and assets under ScanDir are read through
+// FS; with FS nil every other path is read from the OS filesystem, while a
+// custom FS makes paths outside ScanDir unreadable.
 type Options struct {
-	Root       string
-	ScanDir    string
-	Files      []string
+	// Root is the entry page reachability starts from.
+	Root string
+	// Roots are further entry pages.
+	Roots []string
+	// ScanDir is the directory the pages were scanned from.
+	ScanDir string
+	// ScanDirs are further scan directories; links into any of them are
+	// checked. Site generator features treat ScanDir as the site.
+	ScanDirs []string
+	// FS holds the files under ScanDir, rooted there. Nil reads the OS
+	// filesystem.
+	FS fs.FS
+	// SiteRoot is where root-relative links such as /guide.md resolve.
+	// Empty uses ScanDir.
+	SiteRoot string
+	// Files are the pages to parse; they become the graph nodes.
+	Files []string
+	// Extensions mark link targets as pages, which are reported as
+	// unresolved when they are not in Files.
 	Extensions []string
+	// Assets are the non-page files found by the scan; they become graph
+	// nodes so links can reach them.
+	Assets []string
+	// AssetPatterns are the asset globs. A link to a missing file that
+	// matches one is reported as an unresolved asset; other non-page targets
+	// are ignored.
+	AssetPatterns []string
+	// Candidates are tried in order for extensionless and directory links:
+	// suffixes are appended to the target and names starting with "/" are
+	// looked up inside the linked directory.
+	Candidates []string
+	// AnchorStyle is the slug style #fragment links are checked against.
+	// Empty skips those checks; wikilink heading and block refs are checked
+	// either way.
+	AnchorStyle slug.Style
+	// WikiLinks selects wikilink resolution. Empty behaves like
+	// WikiLinkRelative.
+	WikiLinks WikiLinkMode
+	// Drafts selects how pages with draft front matter are handled. Empty
+	// behaves like DraftsInclude.
+	Drafts DraftMode
+	// Hugo resolves ref and relref shortcodes, and with HugoSections also
+	// links each _index.md to the pages of its section.
+	Hugo HugoMode
+	// Jekyll resolves Liquid link, post_url and include tags and links to
+	// front matter permalinks.
+	Jekyll bool
+	// MyST resolves toctree directives, {doc} and {ref} roles against
+	// docnames and labels.
+	MyST bool
+	// Nav lists the pages of a navigation file. They are traversed as
+	// further roots; missing pages and draft entries become warnings.
+	Nav []nav.Entry
+	// NavOnly makes Nav authoritative: pages it does not list stay orphans
+	// even when a listed page links to them.
+	NavOnly bool
+	// Extractors parse each page by extension. Nil uses
+	// parser.DefaultExtractors with the Hugo, Jekyll and MyST syntax enabled
+	// as configured.
+	Extractors parser.Extractors
+	// MaxWorkers caps the parse and link workers. Zero uses GOMAXPROCS.
 	MaxWorkers int
+	// FollowSymlinks gives every page its symlink-free path as identity when
+	// that path lies inside a scan directory.
+	FollowSymlinks bool
+	// Progress, when set, is called after each file is parsed.
+	Progress func(parsed, total int)
 }
 
+type WikiLinkMode string
+
+const (
+	WikiLinkRelative WikiLinkMode = "relative"
+	WikiLinkShortest WikiLinkMode = "shortest"
+)
+
+type DraftMode string
+
+const (
+	DraftsInclude DraftMode = "include"
+	DraftsExclude DraftMode = "exclude"
+	DraftsNoLinks DraftMode = "no-links"
+)
+
 type Graph struct {
-	Root      string
-	Adjacency map[string][]string
-	Warnings  []string
+	Root          string
+	Roots         []string
+	NavRoots      []string
+	NavOnly       bool
+	Adjacency     map[string][]string
+	Edges         map[string][]Edge
+	Assets        []string
+	Excluded      []string
+	OrphanAllowed []string
+	Warnings      []Warning
+}
+
+type Edge struct {
+	Target string
+	Link   parser.Link
+}
+
+type WarningKind string
+
+const (
+	WarningUnresolvedLink  WarningKind = "unresolved-link"
+	WarningUnresolvedAsset WarningKind = "unresolved-asset"
+	WarningBrokenFragment  WarningKind = "broken-fragment"
+	WarningAmbiguousWiki   WarningKind = "ambiguous-wikilink"
+	WarningAmbiguousRef    WarningKind = "ambiguous-ref"
+	WarningUnresolvedNav   WarningKind = "unresolved-nav"
+	WarningDraftNav        WarningKind = "draft-chapter"
+)
+
+type Warning struct {
+	Kind       WarningKind
+	Source     string
+	Target     string
+	Link       parser.Link
+	Candidates []string
 }
 
 type Analysis struct {
-	Reachable       []string
-	Orphans         []string
-	OrphansRelative []string
-	ReachableSet    map[string]struct{}
+	Reachable            []string
+	Orphans              []string
+	OrphansRelative      []string
+	OrphanAssets         []string
+	OrphanAssetsRelative []string
+	ReachableSet         map[string]struct{}
 }
 
 type pathIndex struct {
@@ -40,37 +159,121 @@ type pathIndex struct {
 }
 
 type edgeBuildResult struct {
-	src      string
-	targets  []string
-	warnings []string
-	err      error
+	src       string
+	targets   []string
+	edges     []Edge
+	warnings  []Warning
+	fragments []fragmentRef
+	err       error
+}
+
+// page is what the link pass needs from a parsed document: its links and
+// anchors, and the front matter and labels other pages resolve against.
+// Headings and the rest of the document are dropped once it is parsed.
+type page struct {
+	links       []parser.Link
+	anchors     fileAnchors
+	frontMatter parser.FrontMatter
+	labels      []string
+}
+
+type parsedSource struct {
+	src  string
+	page page
+	err  error
+}
+
+type fileAnchors struct {
+	ids      map[string]struct{}
+	headings map[string]struct{}
+	blocks   map[string]struct{}
+}
+
+type fragmentRef struct {
+	source string
+	target string
+	link   parser.Link
 }
 
 type buildState struct {
-	rootAbs    string
-	scanDirAbs string
-	extSet     map[string]struct{}
-	inventory  map[string]struct{}
-	sources    []string
-	adj        map[string][]string
+	rootAbs       string
+	rootsAbs      []string
+	scanDirAbs    string
+	scanDirs      []string
+	siteRootAbs   string
+	fsys          fs.FS
+	osFS          bool
+	symlinks      bool
+	extSet        map[string]struct{}
+	inventory     map[string]struct{}
+	assets        map[string]struct{}
+	assetPatterns []string
+	candidates    []string
+	anchorStyle   slug.Style
+	wikiLinks     WikiLinkMode
+	wikiIndex     map[string][]string
+	aliasIndex    map[string][]string
+	draftMode     DraftMode
+	drafts        map[string]struct{}
+	extractors    parser.Extractors
+	hugo          HugoMode
+	sections      map[string][]string
+	jekyll        bool
+	permalinks    map[string]string
+	posts         map[string][]string
+	myst          bool
+	labels        map[string][]string
+	orphanAllowed []string
+	pages         map[string]page
+	sources       []string
+	adj           map[string][]string
 }
 
-func Build(opts Options) (*Graph, error) {
+// Build parses every file and resolves its links. It stops at the first
+// error or when ctx is done, without waiting for files still being read.
+func Build(ctx context.Context, opts Options) (*Graph, error) {
 	state, err := prepareBuildState(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	results := runEdgeWorkers(state.sources, state.scanDirAbs, state.extSet, state.inventory, opts.Extensions, opts.MaxWorkers)
-	warnings, err := applyEdgeResults(state.adj, results)
+	if err := state.parseSources(ctx, opts.MaxWorkers, opts.Progress); err != nil {
+		return nil, err
+	}
+	excluded := state.applyFrontMatter()
+	if state.hugo == HugoSections {
+		state.sections = state.buildHugoSections()
+	}
+	if state.jekyll {
+		state.indexJekyll()
+	}
+	if state.myst {
+		state.indexLabels()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := runWorkers(ctx, state.sources, opts.MaxWorkers, state.buildEdgesForSource)
+	edges := make(map[string][]Edge, len(state.adj))
+	warnings, err := applyEdgeResults(ctx, state.pages, state.adj, edges, results)
 	if err != nil {
 		return nil, err
 	}
+	navRoots, navWarnings := state.navRoots(opts.Nav)
+	warnings = append(warnings, navWarnings...)
+	sortWarnings(warnings)
 
 	return &Graph{
-		Root:      state.rootAbs,
-		Adjacency: state.adj,
-		Warnings:  warnings,
+		Root:          state.rootAbs,
+		Roots:         state.rootsAbs,
+		NavRoots:      navRoots,
+		NavOnly:       opts.NavOnly,
+		Adjacency:     state.adj,
+		Edges:         edges,
+		Assets:        sortedKeys(state.assets),
+		Excluded:      excluded,
+		OrphanAllowed: state.orphanAllowed,
+		Warnings:      warnings,
 	}, nil
 }
 
@@ -90,24 +293,158 @@ func prepareBuildState(opts Options) (buildState, error) {
 	if err != nil {
 		return buildState{}, fmt.Errorf("resolve scan dir: %w", err)
 	}
+	siteRootAbs := scanDirAbs
+	if strings.TrimSpace(opts.SiteRoot) != "" {
+		siteRootAbs, err = pathutil.NormalizeAbs(opts.SiteRoot)
+		if err != nil {
+			return buildState{}, fmt.Errorf("resolve site root: %w", err)
+		}
+	}
+	rootsAbs, err := absPaths(opts.Roots)
+	if err != nil {
+		return buildState{}, fmt.Errorf("resolve root: %w", err)
+	}
+	extraDirs, err := absPaths(opts.ScanDirs)
+	if err != nil {
+		return buildState{}, fmt.Errorf("resolve scan dir: %w", err)
+	}
+
+	files, assetFiles := opts.Files, opts.Assets
+	if opts.FollowSymlinks {
+		scanDirAbs, siteRootAbs = realDir(scanDirAbs), realDir(siteRootAbs)
+		for i, dir := range extraDirs {
+			extraDirs[i] = realDir(dir)
+		}
+	}
+	scanDirs := append([]string{scanDirAbs}, extraDirs...)
+	if opts.FollowSymlinks {
+		rootAbs = pathutil.CanonicalIn(scanDirs, rootAbs)
+		for i, root := range rootsAbs {
+			rootsAbs[i] = pathutil.CanonicalIn(scanDirs, root)
+		}
+		if files, err = canonicalPaths(scanDirs, files); err != nil {
+			return buildState{}, err
+		}
+		if assetFiles, err = canonicalPaths(scanDirs, assetFiles); err != nil {
+			return buildState{}, err
+		}
+	}
 
 	extSet := pathutil.ExtensionSet(opts.Extensions)
-	inventory, adj, err := buildInventory(opts.Files)
+	inventory, adj, err := buildInventory(files)
 	if err != nil {
 		return buildState{}, err
 	}
 	sources := sortedKeys(inventory)
+	assets, err := buildAssetInventory(assetFiles, adj)
+	if err != nil {
+		return buildState{}, err
+	}
+	fsys := opts.FS
+	if fsys == nil {
+		fsys = os.DirFS(scanDirAbs)
+	}
+	extractors := opts.Extractors
+	if extractors == nil {
+		extractors = parser.DefaultExtractors(parser.Options{Hugo: opts.Hugo.resolvesRefs(), Jekyll: opts.Jekyll, MyST: opts.MyST})
+	}
 
 	return buildState{
-		rootAbs:    rootAbs,
-		scanDirAbs: scanDirAbs,
-		extSet:     extSet,
-		inventory:  inventory,
-		sources:    sources,
-		adj:        adj,
+		rootAbs:       rootAbs,
+		rootsAbs:      rootsAbs,
+		scanDirAbs:    scanDirAbs,
+		scanDirs:      scanDirs,
+		siteRootAbs:   siteRootAbs,
+		fsys:          fsys,
+		osFS:          opts.FS == nil,
+		symlinks:      opts.FollowSymlinks,
+		extSet:        extSet,
+		inventory:     inventory,
+		assets:        assets,
+		assetPatterns: opts.AssetPatterns,
+		candidates:    opts.Candidates,
+		anchorStyle:   opts.AnchorStyle,
+		wikiLinks:     opts.WikiLinks,
+		aliasIndex:    make(map[string][]string),
+		draftMode:     opts.Drafts,
+		drafts:        make(map[string]struct{}),
+		extractors:    extractors,
+		hugo:          opts.Hugo,
+		jekyll:        opts.Jekyll,
+		myst:          opts.MyST,
+		sources:       sources,
+		adj:           adj,
 	}, nil
 }
 
+// realDir resolves symlinks in dir, keeping dir when it does not exist.
+func realDir(dir string) string {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		return real
+	}
+	return dir
+}
+
+func canonicalPaths(dirs []string, files []string) ([]string, error) {
+	out := make([]string, 0, len(files))
+	for _, file := range files {
+		abs, err := pathutil.NormalizeAbs(file)
+		if err != nil {
+			return nil, fmt.Errorf("resolve file path %q: %w", file, err)
+		}
+		out = append(out, pathutil.CanonicalIn(dirs, abs))
+	}
+	return out, nil
+}
+
+func absPaths(paths []string) ([]string, error) {
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := pathutil.NormalizeAbs(path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, abs)
+	}
+	return out, nil
+}
+
+// canonical maps path to its node identity when following symlinks.
+func (s *buildState) canonical(path string) string {
+	if !s.symlinks {
+		return path
+	}
+	return pathutil.CanonicalIn(s.scanDirs, path)
+}
+
+func buildAssetInventory(files []string, adj map[string][]string) (map[string]struct{}, error) {
+	assets := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		abs, err := pathutil.NormalizeAbs(file)
+		if err != nil {
+			return nil, fmt.Errorf("resolve asset path %q: %w", file, err)
+		}
+		if _, ok := adj[abs]; ok {
+			continue
+		}
+		assets[abs] = struct{}{}
+		adj[abs] = []string{}
+	}
+	return assets, nil
+}
+
+func buildWikiIndex(adj map[string][]string) map[string][]string {
+	index := make(map[string][]string, len(adj))
+	for node := range adj {
+		key := strings.ToLower(filepath.Base(node))
+		index[key] = append(index[key], node)
+	}
+	for key := range index {
+		sort.Strings(index[key])
+	}
+	return index
+}
+
 func buildInventory(files []string) (map[string]struct{}, map[string][]string, error) {
 	inventory := make(map[string]struct{}, len(files))
 	adj := make(map[string][]string, len(files))
@@ -131,8 +468,10 @@ func sortedKeys(set map[string]struct{}) []string {
 	return out
 }
 
-func runEdgeWorkers(sources []string, scanDir string, extSet map[string]struct{}, inventory map[string]struct{}, extensions []string, maxWorkers int) <-chan edgeBuildResult {
-	results := make(chan edgeBuildResult, len(sources))
+// runWorkers runs work over sources on a bounded pool. No new source is
+// started once ctx is done, so callers stop the pool early by cancelling it.
+func runWorkers[T any](ctx context.Context, sources []string, maxWorkers int, work func(string) T) <-chan T {
+	results := make(chan T, len(sources))
 	if len(sources) == 0 {
 		close(results)
 		return results
@@ -146,23 +485,115 @@ func runEdgeWorkers(sources []string, scanDir string, extSet map[string]struct{}
 		go func() {
 			defer wg.Done()
 			for src := range jobs {
-				results <- buildEdgesForSource(src, scanDir, extSet, inventory, extensions)
+				results <- work(src)
 			}
 		}()
 	}
 
 	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(results)
+		}()
 		for _, src := range sources {
-			jobs <- src
+			select {
+			case jobs <- src:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(jobs)
-		wg.Wait()
-		close(results)
 	}()
 
 	return results
 }
 
+// receive returns the next worker result. It returns ctx's error once ctx is
+// done, including when the pool closed early because of it; ok is false when
+// every result has been received.
+func receive[T any](ctx context.Context, results <-chan T) (res T, ok bool, err error) {
+	select {
+	case <-ctx.Done():
+		return res, false, ctx.Err()
+	case res, ok = <-results:
+		if !ok {
+			return res, false, ctx.Err()
+		}
+		return res, true, nil
+	}
+}
+
+func (s *buildState) parseSources(ctx context.Context, maxWorkers int, progress func(parsed, total int)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.pages = make(map[string]page, len(s.sources))
+	results := runWorkers(ctx, s.sources, maxWorkers, s.parseSource)
+	for {
+		res, ok, err := receive(ctx, results)
+		if err != nil || !ok {
+			return err
+		}
+		if res.err != nil {
+			return res.err
+		}
+		s.pages[res.src] = res.page
+		if progress != nil {
+			progress(len(s.pages), len(s.sources))
+		}
+	}
+}
+
+func (s *buildState) parseSource(src string) parsedSource {
+	content, err := s.readFile(src)
+	if err != nil {
+		return parsedSource{src: src, err: fmt.Errorf("read source file %q: %w", src, err)}
+	}
+	doc := s.extractors.For(src).Extract(string(content))
+	pg := page{links: doc.Links, frontMatter: doc.FrontMatter, labels: doc.Labels, anchors: collectAnchors(doc, s.anchorStyle)}
+	return parsedSource{src: src, page: pg}
+}
+
+// applyFrontMatter records per-file front matter (aliases, drafts, orphan
+// policy) and drops excluded drafts from the inventory before links resolve.
+func (s *buildState) applyFrontMatter() []string {
+	excluded := make([]string, 0)
+	sources := make([]string, 0, len(s.sources))
+	for _, src := range s.sources {
+		fm := s.pages[src].frontMatter
+		if fm.Draft && s.draftMode != "" && s.draftMode != DraftsInclude {
+			s.drafts[src] = struct{}{}
+			if s.draftMode == DraftsExclude {
+				delete(s.inventory, src)
+				delete(s.adj, src)
+				delete(s.pages, src)
+				excluded = append(excluded, src)
+				continue
+			}
+		}
+		sources = append(sources, src)
+		for _, alias := range fm.Aliases {
+			if key := aliasKey(alias); key != "" {
+				s.aliasIndex[key] = append(s.aliasIndex[key], src)
+			}
+		}
+		if fm.OrphanAllowed {
+			s.orphanAllowed = append(s.orphanAllowed, src)
+		}
+	}
+	s.sources = sources
+	s.wikiIndex = buildWikiIndex(s.adj)
+	return excluded
+}
+
+func aliasKey(alias string) string {
+	key := strings.ToLower(strings.TrimSpace(alias))
+	if key != "" && path.Ext(key) == "" {
+		key += ".md"
+	}
+	return key
+}
+
 func resolveWorkerCount(sourceCount int, maxWorkers int) int {
 	workerCount := runtime.GOMAXPROCS(0)
 	if maxWorkers > 0 && workerCount > maxWorkers {
@@ -180,71 +611,456 @@ func resolveWorkerCount(sourceCount int, maxWorkers int) int {
 	return workerCount
 }
 
-func applyEdgeResults(adj map[string][]string, results <-chan edgeBuildResult) ([]string, error) {
-	warningSet := make(map[string]struct{})
-	for res := range results {
+func applyEdgeResults(ctx context.Context, pages map[string]page, adj map[string][]string, edges map[string][]Edge, results <-chan edgeBuildResult) ([]Warning, error) {
+	warnings := make([]Warning, 0)
+	fragments := make([]fragmentRef, 0)
+	for {
+		res, ok, err := receive(ctx, results)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
 		if res.err != nil {
 			return nil, res.err
 		}
 		adj[res.src] = res.targets
-		for _, warning := range res.warnings {
-			warningSet[warning] = struct{}{}
+		edges[res.src] = res.edges
+		warnings = append(warnings, res.warnings...)
+		fragments = append(fragments, res.fragments...)
+	}
+	return append(warnings, brokenFragments(pages, fragments)...), nil
+}
+
+func brokenFragments(pages map[string]page, fragments []fragmentRef) []Warning {
+	warnings := make([]Warning, 0)
+	for _, ref := range fragments {
+		if pages[ref.target].anchors.has(ref.link) || strings.EqualFold(ref.link.Fragment, "top") {
+			continue
 		}
+		warnings = append(warnings, Warning{Kind: WarningBrokenFragment, Source: ref.source, Target: ref.target, Link: ref.link})
 	}
-	return toSortedSlice(warningSet), nil
+	return warnings
 }
 
-func buildEdgesForSource(src, scanDir string, extSet map[string]struct{}, inventory map[string]struct{}, extensions []string) edgeBuildResult {
-	content, err := os.ReadFile(src)
-	if err != nil {
-		return edgeBuildResult{src: src, err: fmt.Errorf("read markdown file %q: %w", src, err)}
+// collectAnchors records the wikilink heading keys and block ids of doc, and
+// with a slug style also its #fragment ids.
+func collectAnchors(doc parser.Document, style slug.Style) fileAnchors {
+	anchors := fileAnchors{
+		headings: make(map[string]struct{}, len(doc.Headings)),
+		blocks:   make(map[string]struct{}, len(doc.BlockIDs)),
+	}
+	for _, heading := range doc.Headings {
+		anchors.headings[wikiHeadingKey(heading.Text)] = struct{}{}
 	}
+	for _, id := range doc.BlockIDs {
+		anchors.blocks[id] = struct{}{}
+	}
+	if style == "" {
+		return anchors
+	}
+
+	anchors.ids = make(map[string]struct{}, len(doc.Headings)+len(doc.Anchors))
+	slugger := slug.New(style)
+	for _, heading := range doc.Headings {
+		if heading.ID != "" {
+			slugger.Reserve(heading.ID)
+			anchors.ids[heading.ID] = struct{}{}
+		}
+	}
+	for _, heading := range doc.Headings {
+		if heading.ID == "" {
+			anchors.ids[slugger.Slug(heading.Text)] = struct{}{}
+		}
+	}
+	for _, anchor := range doc.Anchors {
+		anchors.ids[anchor] = struct{}{}
+	}
+	return anchors
+}
+
+// checksFragment reports whether link's fragment is validated. Wikilink
+// heading and block refs always are; other fragments need a slug style.
+func (s *buildState) checksFragment(link parser.Link) bool {
+	return link.Fragment != "" && (link.IsWiki() || s.anchorStyle != "")
+}
+
+func (a fileAnchors) has(link parser.Link) bool {
+	fragment := link.Fragment
+	if strings.HasPrefix(fragment, "^") {
+		_, ok := a.blocks[fragment[1:]]
+		return ok
+	}
+	if link.IsWiki() {
+		_, ok := a.headings[wikiHeadingKey(fragment)]
+		return ok
+	}
+	_, ok := a.ids[fragment]
+	return ok
+}
+
+// wikiHeadingKey compares headings the way Obsidian-style heading refs are
+// written: case-insensitive, with characters that cannot appear in a
+// wikilink treated as spaces.
+func wikiHeadingKey(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`#^|[]:%\`, r) {
+			return ' '
+		}
+		return r
+	}, text)
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
 
-	links := parser.ExtractLocalMarkdownLinks(string(content), extensions)
+func (s *buildState) buildEdgesForSource(src string) edgeBuildResult {
+	links := s.pages[src].links
+	if _, draft := s.drafts[src]; draft && s.draftMode == DraftsNoLinks {
+		links = nil
+	}
 	targetSet := make(map[string]struct{})
-	warningSet := make(map[string]struct{})
+	edges := make([]Edge, 0, len(links))
+	warnings := make([]Warning, 0)
+	fragments := make([]fragmentRef, 0)
 	srcDir := filepath.Dir(src)
 
 	for _, link := range links {
-		targetPath := filepath.Clean(filepath.Join(srcDir, filepath.FromSlash(link)))
-		target, err := pathutil.NormalizeAbs(targetPath)
+		if link.Target == "" {
+			if s.checksFragment(link) {
+				fragments = append(fragments, fragmentRef{source: src, target: src, link: link})
+			}
+			continue
+		}
+		if s.myst && isSphinxLink(link) {
+			resolved, matches := s.resolveSphinxLink(src, link)
+			if len(matches) > 1 {
+				warnings = append(warnings, Warning{Kind: WarningAmbiguousRef, Source: src, Link: link, Candidates: matches})
+				continue
+			}
+			if len(resolved) == 0 {
+				warnings = append(warnings, Warning{Kind: WarningUnresolvedLink, Source: src, Target: link.Target, Link: link})
+				continue
+			}
+			for _, target := range resolved {
+				if !s.isExcluded(target) {
+					targetSet[target] = struct{}{}
+					edges = append(edges, Edge{Target: target, Link: link})
+				}
+			}
+			continue
+		}
+		linked := s.linkPath(srcDir, link.Target)
+		lookedUp := false
+		if link.IsWiki() {
+			resolved, matches := s.resolveWikiLink(srcDir, link.Target)
+			if len(matches) > 1 {
+				warnings = append(warnings, Warning{Kind: WarningAmbiguousWiki, Source: src, Link: link, Candidates: matches})
+				continue
+			}
+			if resolved != "" {
+				linked = resolved
+			}
+		}
+		if link.Kind == parser.LinkShortcode && s.hugo.resolvesRefs() {
+			resolved, matches := s.resolveHugoRef(srcDir, link.Target)
+			if len(matches) > 1 {
+				warnings = append(warnings, Warning{Kind: WarningAmbiguousRef, Source: src, Link: link, Candidates: matches})
+				continue
+			}
+			if resolved == "" {
+				warnings = append(warnings, Warning{Kind: WarningUnresolvedLink, Source: src, Target: linked, Link: link})
+				continue
+			}
+			linked, lookedUp = resolved, true
+		}
+		if resolved, ok := s.resolveIncludeDirective(srcDir, link); ok {
+			linked, lookedUp = resolved, true
+		}
+		if s.jekyll {
+			if resolved, handled := s.resolveJekyllLink(src, srcDir, link); handled {
+				if resolved == "" {
+					warnings = append(warnings, Warning{Kind: WarningUnresolvedLink, Source: src, Target: linked, Link: link})
+					continue
+				}
+				linked, lookedUp = resolved, true
+			}
+		}
+		target, err := pathutil.NormalizeAbs(linked)
 		if err != nil {
-			return edgeBuildResult{src: src, err: fmt.Errorf("resolve linked path %q in %q: %w", link, src, err)}
+			return edgeBuildResult{src: src, err: fmt.Errorf("resolve linked path %q in %q: %w", link.Target, src, err)}
 		}
+		target = s.canonical(target)
 
-		if !pathutil.IsWithinDir(scanDir, target) {
+		if _, ok := pathutil.ContainingDir(s.scanDirs, target); !ok {
 			continue
 		}
-		if _, ok := extSet[strings.ToLower(filepath.Ext(target))]; !ok {
+		if isExtensionless(link.Target) && !lookedUp {
+			resolved, ok := s.resolveCandidates(target, isDirectoryLink(link.Target))
+			if !ok {
+				if len(s.candidates) > 0 && !s.pathExists(target) {
+					warnings = append(warnings, Warning{Kind: WarningUnresolvedLink, Source: src, Target: target, Link: link})
+				}
+				continue
+			}
+			target = resolved
+		}
+		if s.isExcluded(target) {
 			continue
 		}
-		if _, ok := inventory[target]; !ok {
-			warningSet[unresolvedWarning(src, target)] = struct{}{}
+		kind, ok := s.classifyTarget(target)
+		if !ok {
+			continue
+		}
+		if kind != "" {
+			warnings = append(warnings, Warning{Kind: kind, Source: src, Target: target, Link: link})
 			continue
 		}
 		targetSet[target] = struct{}{}
+		edges = append(edges, Edge{Target: target, Link: link})
+		if _, ok := s.inventory[target]; ok && s.checksFragment(link) {
+			fragments = append(fragments, fragmentRef{source: src, target: target, link: link})
+		}
+	}
+
+	for _, edge := range sectionEdges(s.sections[src]) {
+		targetSet[edge.Target] = struct{}{}
+		edges = append(edges, edge)
 	}
 
 	return edgeBuildResult{
-		src:      src,
-		targets:  toSortedSlice(targetSet),
-		warnings: toSortedSlice(warningSet),
+		src:       src,
+		targets:   toSortedSlice(targetSet),
+		edges:     edges,
+		warnings:  warnings,
+		fragments: fragments,
+	}
+}
+
+// resolveWikiLink picks the file a wikilink names. Relative mode keeps the
+// path from the linking file; shortest mode looks the name up by basename (or
+// trailing path segments) and prefers the path match when several files
+// qualify. Front matter aliases are the fallback in both modes. Ambiguous
+// links return every candidate instead.
+func (s *buildState) resolveWikiLink(srcDir, target string) (string, []string) {
+	if s.wikiLinks == WikiLinkShortest {
+		if resolved, matches := s.shortestWikiMatch(srcDir, target); resolved != "" || len(matches) > 1 {
+			return resolved, matches
+		}
+	} else if linked := s.linkPath(srcDir, target); s.isNode(linked) {
+		return linked, nil
+	}
+
+	aliases := s.aliasIndex[strings.ToLower(target)]
+	switch len(aliases) {
+	case 0:
+		return "", nil
+	case 1:
+		return aliases[0], nil
+	}
+	return "", aliases
+}
+
+func (s *buildState) shortestWikiMatch(srcDir, target string) (string, []string) {
+	if strings.HasPrefix(target, "/") || strings.HasPrefix(target, "../") {
+		return "", nil
+	}
+	want := "/" + strings.ToLower(target)
+	matches := make([]string, 0, 1)
+	for _, node := range s.wikiIndex[strings.ToLower(path.Base(target))] {
+		rel, err := pathutil.RelativeSlash(s.scanDirAbs, node)
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix("/"+strings.ToLower(rel), want) {
+			matches = append(matches, node)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	}
+	for _, preferred := range []string{filepath.Join(srcDir, filepath.FromSlash(target)), filepath.Join(s.scanDirAbs, filepath.FromSlash(target))} {
+		for _, match := range matches {
+			if match == preferred {
+				return match, nil
+			}
+		}
+	}
+	return "", matches
+}
+
+func (s *buildState) isNode(path string) bool {
+	if _, ok := s.inventory[path]; ok {
+		return true
+	}
+	_, ok := s.assets[path]
+	return ok
+}
+
+func (s *buildState) linkPath(srcDir, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return filepath.Join(s.siteRootAbs, filepath.FromSlash(strings.TrimPrefix(target, "/")))
+	}
+	return filepath.Join(srcDir, filepath.FromSlash(target))
+}
+
+func isExtensionless(target string) bool {
+	return isDirectoryLink(target) || path.Ext(target) == ""
+}
+
+func isDirectoryLink(target string) bool {
+	base := path.Base(target)
+	return strings.HasSuffix(target, "/") || base == "." || base == ".."
+}
+
+func (s *buildState) resolveCandidates(target string, isDir bool) (string, bool) {
+	for _, candidate := range s.candidates {
+		var resolved string
+		switch {
+		case strings.HasPrefix(candidate, "/"):
+			resolved = filepath.Join(target, filepath.FromSlash(strings.TrimPrefix(candidate, "/")))
+		case isDir:
+			continue
+		default:
+			resolved = target + candidate
+		}
+		if _, ok := s.inventory[resolved]; ok || s.isExcluded(resolved) {
+			return resolved, true
+		}
 	}
+	return "", false
 }
 
-func unresolvedWarning(src, target string) string {
-	return fmt.Sprintf("unresolved local markdown link: %s -> %s", src, target)
+func (s *buildState) isExcluded(path string) bool {
+	_, ok := s.drafts[path]
+	return ok && s.draftMode == DraftsExclude
 }
 
-func Analyze(g *Graph, scanDir string, allFiles []string) (*Analysis, error) {
+// readFile reads an absolute path under the scan dir from the build FS.
+// Paths outside the scan dir are only read from the OS filesystem.
+func (s *buildState) readFile(path string) ([]byte, error) {
+	name, ok := pathutil.FSPath(s.scanDirAbs, path)
+	if !ok {
+		if s.osFS {
+			return os.ReadFile(path)
+		}
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return fs.ReadFile(s.fsys, name)
+}
+
+// pathExists reports whether path exists. Paths outside the scan dir are
+// only checked on the OS filesystem.
+func (s *buildState) pathExists(path string) bool {
+	name, ok := pathutil.FSPath(s.scanDirAbs, path)
+	if !ok {
+		if !s.osFS {
+			return false
+		}
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	_, err := fs.Stat(s.fsys, name)
+	return err == nil
+}
+
+func (s *buildState) classifyTarget(target string) (WarningKind, bool) {
+	if _, ok := s.extSet[strings.ToLower(filepath.Ext(target))]; ok {
+		if _, ok := s.inventory[target]; !ok {
+			return WarningUnresolvedLink, true
+		}
+		return "", true
+	}
+	if len(s.assetPatterns) == 0 {
+		return "", false
+	}
+	if _, ok := s.assets[target]; ok {
+		return "", true
+	}
+	dir, ok := pathutil.ContainingDir(s.scanDirs, target)
+	if !ok {
+		dir = s.scanDirAbs
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || !pathutil.MatchAnyPattern(s.assetPatterns, rel) {
+		return "", false
+	}
+	return WarningUnresolvedAsset, true
+}
+
+func sortWarnings(warnings []Warning) {
+	sort.Slice(warnings, func(i, j int) bool {
+		a, b := warnings[i], warnings[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Link.Line != b.Link.Line {
+			return a.Link.Line < b.Link.Line
+		}
+		if a.Link.Column != b.Link.Column {
+			return a.Link.Column < b.Link.Column
+		}
+		return a.Kind < b.Kind
+	})
+}
+
+func (w Warning) String() string {
+	return w.Format()
+}
+
+// Format renders the warning with paths relative to the deepest of baseDirs
+// holding each one. Without baseDirs paths stay absolute.
+func (w Warning) Format(baseDirs ...string) string {
+	dirs := nonEmpty(baseDirs)
+	source := w.Source
+	if len(dirs) > 0 {
+		if rel, err := pathutil.RelativeSlashIn(dirs, w.Source); err == nil {
+			source = rel
+		}
+	}
+	location := fmt.Sprintf("%s:%d:%d", source, w.Link.Line, w.Link.Column)
+	switch w.Kind {
+	case WarningUnresolvedAsset:
+		return fmt.Sprintf("unresolved local asset link: %s -> %s", location, w.Link.Destination)
+	case WarningBrokenFragment:
+		return fmt.Sprintf("broken link fragment: %s -> %s", location, w.Link.Destination)
+	case WarningAmbiguousWiki:
+		return fmt.Sprintf("ambiguous wikilink: %s -> %s (matches %s)", location, w.Link.Destination, w.candidateList(dirs))
+	case WarningAmbiguousRef:
+		return fmt.Sprintf("ambiguous ref: %s -> %s (matches %s)", location, w.Link.Destination, w.candidateList(dirs))
+	case WarningUnresolvedNav:
+		return fmt.Sprintf("unresolved nav entry: %s -> %s", location, w.Link.Destination)
+	case WarningDraftNav:
+		return fmt.Sprintf("draft chapter without file: %s -> %s", location, w.Link.Text)
+	default:
+		return fmt.Sprintf("unresolved local markdown link: %s -> %s", location, w.Link.Destination)
+	}
+}
+
+func (w Warning) candidateList(dirs []string) string {
+	candidates := make([]string, 0, len(w.Candidates))
+	for _, candidate := range w.Candidates {
+		if rel, err := pathutil.RelativeSlashIn(dirs, candidate); err == nil {
+			candidate = rel
+		}
+		candidates = append(candidates, candidate)
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// Analyze finds the files and assets that no root reaches. Orphan paths are
+// made relative to the deepest of scanDirs that holds them.
+func Analyze(g *Graph, scanDirs []string, allFiles []string) (*Analysis, error) {
 	if g == nil {
 		return nil, fmt.Errorf("graph is required")
 	}
-	if strings.TrimSpace(scanDir) == "" {
+	if len(scanDirs) == 0 || strings.TrimSpace(scanDirs[0]) == "" {
 		return nil, fmt.Errorf("scan dir is required")
 	}
 
-	scanDirAbs, err := pathutil.NormalizeAbs(scanDir)
+	dirsAbs, err := absPaths(scanDirs)
 	if err != nil {
 		return nil, fmt.Errorf("resolve scan dir: %w", err)
 	}
@@ -253,7 +1069,7 @@ func Analyze(g *Graph, scanDir string, allFiles []string) (*Analysis, error) {
 		idByPath: make(map[string]int, len(allFiles)+len(g.Adjacency)),
 		paths:    make([]string, 0, len(allFiles)+len(g.Adjacency)),
 	}
-	inventory, inventoryIDs, err := indexInventoryFiles(&index, allFiles)
+	inventory, inventoryIDs, err := indexInventoryFiles(&index, withoutPaths(allFiles, g.Excluded))
 	if err != nil {
 		return nil, err
 	}
@@ -261,25 +1077,77 @@ func Analyze(g *Graph, scanDir string, allFiles []string) (*Analysis, error) {
 	if _, ok := inventory[rootID]; !ok {
 		return nil, fmt.Errorf("root markdown file is not in scan result: %s", g.Root)
 	}
+	rootIDs := []int{rootID}
+	for _, root := range g.Roots {
+		id := index.intern(root)
+		if _, ok := inventory[id]; !ok {
+			return nil, fmt.Errorf("root markdown file is not in scan result: %s", root)
+		}
+		if id != rootID {
+			rootIDs = append(rootIDs, id)
+		}
+	}
+	for _, root := range g.NavRoots {
+		if id := index.intern(root); id != rootID {
+			rootIDs = append(rootIDs, id)
+		}
+	}
+
+	assetSet, assetIDs, err := indexInventoryFiles(&index, g.Assets)
+	if err != nil {
+		return nil, err
+	}
 
 	adjacencyIDs := indexAdjacency(&index, g.Adjacency)
-	reachableIDs := traverseReachableIDs(rootID, adjacencyIDs)
-	reachableSet, reachable := reachableFromIDs(&index, reachableIDs)
-	orphans := findOrphans(&index, inventoryIDs, reachableIDs)
-	orphansRelative, err := pathutil.RelativeSlashMany(scanDirAbs, orphans)
+	if g.NavOnly {
+		adjacencyIDs = navOnlyAdjacency(adjacencyIDs, inventory, rootIDs)
+	}
+	reachableIDs := traverseReachableIDs(rootIDs, adjacencyIDs)
+	reachableSet, reachable := reachableFromIDs(&index, reachableIDs, assetSet)
+	orphans := withoutPaths(findOrphans(&index, inventoryIDs, reachableIDs), g.OrphanAllowed)
+	orphansRelative, err := relativeSlashIn(dirsAbs, orphans)
 	if err != nil {
 		return nil, fmt.Errorf("convert orphan path to relative: %w", err)
 	}
 	sort.Strings(orphansRelative)
 
+	orphanAssets := findOrphans(&index, assetIDs, reachableIDs)
+	orphanAssetsRelative, err := relativeSlashIn(dirsAbs, orphanAssets)
+	if err != nil {
+		return nil, fmt.Errorf("convert orphan asset path to relative: %w", err)
+	}
+	sort.Strings(orphanAssetsRelative)
+
 	return &Analysis{
-		Reachable:       reachable,
-		Orphans:         orphans,
-		OrphansRelative: orphansRelative,
-		ReachableSet:    reachableSet,
+		Reachable:            reachable,
+		Orphans:              orphans,
+		OrphansRelative:      orphansRelative,
+		OrphanAssets:         orphanAssets,
+		OrphanAssetsRelative: orphanAssetsRelative,
+		ReachableSet:         reachableSet,
 	}, nil
 }
 
+func withoutPaths(paths []string, remove []string) []string {
+	if len(remove) == 0 {
+		return paths
+	}
+	removed := make(map[string]struct{}, len(remove))
+	for _, p := range remove {
+		removed[p] = struct{}{}
+	}
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if abs, err := pathutil.NormalizeAbs(p); err == nil {
+			if _, ok := removed[abs]; ok {
+				continue
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
 func indexInventoryFiles(index *pathIndex, allFiles []string) (map[int]struct{}, []int, error) {
 	inventory := make(map[int]struct{}, len(allFiles))
 	inventoryIDs := make([]int, 0, len(allFiles))
@@ -311,9 +1179,12 @@ func indexAdjacency(index *pathIndex, adjacency map[string][]string) map[int][]i
 	return adjacencyIDs
 }
 
-func reachableFromIDs(index *pathIndex, reachableIDs map[int]struct{}) (map[string]struct{}, []string) {
+func reachableFromIDs(index *pathIndex, reachableIDs map[int]struct{}, excluded map[int]struct{}) (map[string]struct{}, []string) {
 	reachableSet := make(map[string]struct{}, len(reachableIDs))
 	for id := range reachableIDs {
+		if _, ok := excluded[id]; ok {
+			continue
+		}
 		reachableSet[index.path(id)] = struct{}{}
 	}
 	reachable := toSortedSlice(reachableSet)
@@ -332,9 +1203,12 @@ func findOrphans(index *pathIndex, inventoryIDs []int, reachableIDs map[int]stru
 	return orphans
 }
 
-func traverseReachableIDs(root int, adjacency map[int][]int) map[int]struct{} {
+func traverseReachableIDs(roots []int, adjacency map[int][]int) map[int]struct{} {
 	visited := make(map[int]struct{})
-	stack := []int{root}
+	stack := make([]int, 0, len(roots))
+	for i := len(roots) - 1; i >= 0; i-- {
+		stack = append(stack, roots[i])
+	}
 
 	for len(stack) > 0 {
 		n := len(stack) - 1
@@ -380,11 +1254,13 @@ func toSortedSlice(set map[string]struct{}) []string {
 	return out
 }
 
-func ExportDOT(g *Graph, scanDir string) (string, error) {
+// ExportDOT renders g in Graphviz DOT syntax. Node labels are relative to
+// the deepest of scanDirs holding each node, or absolute without scanDirs.
+func ExportDOT(g *Graph, scanDirs ...string) (string, error) {
 	if g == nil {
 		return "", fmt.Errorf("graph is required")
 	}
-	scanDirAbs, err := normalizedScanDir(scanDir)
+	scanDirsAbs, err := normalizedScanDirs(scanDirs)
 	if err != nil {
 		return "", err
 	}
@@ -396,7 +1272,7 @@ func ExportDOT(g *Graph, scanDir string) (string, error) {
 
 	lines := []string{"digraph gorphan {"}
 	for _, src := range nodes {
-		srcLabel, err := relativeLabel(scanDirAbs, src)
+		srcLabel, err := relativeLabel(scanDirsAbs, src)
 		if err != nil {
 			return "", err
 		}
@@ -405,7 +1281,7 @@ func ExportDOT(g *Graph, scanDir string) (string, error) {
 			continue
 		}
 		for _, dst := range g.Adjacency[src] {
-			dstLabel, err := relativeLabel(scanDirAbs, dst)
+			dstLabel, err := relativeLabel(scanDirsAbs, dst)
 			if err != nil {
 				return "", err
 			}
@@ -416,11 +1292,13 @@ func ExportDOT(g *Graph, scanDir string) (string, error) {
 	return strings.Join(lines, "\n"), nil
 }
 
-func ExportMermaid(g *Graph, scanDir string) (string, error) {
+// ExportMermaid renders g as a Mermaid flowchart, labelling nodes like
+// ExportDOT.
+func ExportMermaid(g *Graph, scanDirs ...string) (string, error) {
 	if g == nil {
 		return "", fmt.Errorf("graph is required")
 	}
-	scanDirAbs, err := normalizedScanDir(scanDir)
+	scanDirsAbs, err := normalizedScanDirs(scanDirs)
 	if err != nil {
 		return "", err
 	}
@@ -432,7 +1310,7 @@ func ExportMermaid(g *Graph, scanDir string) (string, error) {
 
 	lines := []string{"graph TD"}
 	for _, src := range nodes {
-		srcLabel, err := relativeLabel(scanDirAbs, src)
+		srcLabel, err := relativeLabel(scanDirsAbs, src)
 		if err != nil {
 			return "", err
 		}
@@ -441,7 +1319,7 @@ func ExportMermaid(g *Graph, scanDir string) (string, error) {
 			continue
 		}
 		for _, dst := range g.Adjacency[src] {
-			dstLabel, err := relativeLabel(scanDirAbs, dst)
+			dstLabel, err := relativeLabel(scanDirsAbs, dst)
 			if err != nil {
 				return "", err
 			}
@@ -451,22 +1329,41 @@ func ExportMermaid(g *Graph, scanDir string) (string, error) {
 	return strings.Join(lines, "\n"), nil
 }
 
-func normalizedScanDir(scanDir string) (string, error) {
-	if strings.TrimSpace(scanDir) == "" {
-		return "", nil
+func relativeSlashIn(dirs []string, paths []string) ([]string, error) {
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, err := pathutil.RelativeSlashIn(dirs, path)
+		if err != nil {
+			return nil, fmt.Errorf("convert path to relative: %w", err)
+		}
+		out = append(out, rel)
 	}
-	scanDirAbs, err := pathutil.NormalizeAbs(scanDir)
+	return out, nil
+}
+
+func normalizedScanDirs(scanDirs []string) ([]string, error) {
+	dirs, err := absPaths(nonEmpty(scanDirs))
 	if err != nil {
-		return "", fmt.Errorf("resolve scan dir: %w", err)
+		return nil, fmt.Errorf("resolve scan dir: %w", err)
+	}
+	return dirs, nil
+}
+
+func nonEmpty(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			out = append(out, value)
+		}
 	}
-	return scanDirAbs, nil
+	return out
 }
 
-func relativeLabel(scanDirAbs, abs string) (string, error) {
-	if scanDirAbs == "" {
+func relativeLabel(scanDirsAbs []string, abs string) (string, error) {
+	if len(scanDirsAbs) == 0 {
 		return filepath.ToSlash(abs), nil
 	}
-	rel, err := pathutil.RelativeSlash(scanDirAbs, abs)
+	rel, err := pathutil.RelativeSlashIn(scanDirsAbs, abs)
 	if err != nil {
 		return "", fmt.Errorf("make relative label: %w", err)
 	}
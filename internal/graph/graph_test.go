@@ -1,12 +1,21 @@
 package graph
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
 
-	"gorphan/internal/testutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/nav"
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/slug"
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
 )
 
 func TestBuild_GraphFiltersTargets(t *testing.T) {
@@ -21,7 +30,7 @@ func TestBuild_GraphFiltersTargets(t *testing.T) {
 	testutil.MustWrite(t, b, "[a](./a.md)")
 	testutil.MustWrite(t, outside, "# outside")
 
-	g, err := Build(Options{
+	g, err := Build(context.Background(), Options{
 		Root:       root,
 		ScanDir:    dir,
 		Files:      []string{root, a, b},
@@ -48,8 +57,756 @@ func TestBuild_GraphFiltersTargets(t *testing.T) {
 	}
 }
 
+func TestBuild_ReadsFromFS(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		"index.md":       {Data: []byte("[guide](guide) [missing](missing.md)")},
+		"guide/index.md": {Data: []byte("[back](../index.md)")},
+		"orphan.md":      {Data: []byte("# orphan")},
+	}
+	root := filepath.Join(dir, "index.md")
+	guide := filepath.Join(dir, "guide", "index.md")
+	orphan := filepath.Join(dir, "orphan.md")
+
+	g, err := Build(context.Background(), Options{
+		Root:       root,
+		ScanDir:    dir,
+		FS:         fsys,
+		Files:      []string{root, guide, orphan},
+		Extensions: []string{".md"},
+		Candidates: []string{".md", "/index.md"},
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	want := map[string][]string{
+		root:   {guide},
+		guide:  {root},
+		orphan: {},
+	}
+	if !reflect.DeepEqual(g.Adjacency, want) {
+		t.Fatalf("unexpected adjacency\nwant: %#v\n got: %#v", want, g.Adjacency)
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Target != filepath.Join(dir, "missing.md") {
+		t.Fatalf("expected one unresolved warning, got: %#v", g.Warnings)
+	}
+
+	analysis, err := Analyze(g, []string{dir}, []string{root, guide, orphan})
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if !reflect.DeepEqual(analysis.OrphansRelative, []string{"orphan.md"}) {
+		t.Fatalf("unexpected orphans: %#v", analysis.OrphansRelative)
+	}
+}
+
+func TestBuild_ProgressAndCancel(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		"index.md": {Data: []byte("[a](a.md)")},
+		"a.md":     {Data: []byte("# a")},
+	}
+	files := []string{filepath.Join(dir, "index.md"), filepath.Join(dir, "a.md")}
+	opts := Options{Root: files[0], ScanDir: dir, FS: fsys, Files: files, Extensions: []string{".md"}}
+
+	var last, total int
+	opts.Progress = func(p, t int) { last, total = p, t }
+	if _, err := Build(context.Background(), opts); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if last != 2 || total != 2 {
+		t.Fatalf("unexpected progress: parsed=%d total=%d", last, total)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Build(ctx, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBuild_StopsOnReadError(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	files := make([]string, 0, 64)
+	fsys := fstest.MapFS{}
+	for i := 0; i < 64; i++ {
+		name := fmt.Sprintf("page%02d.md", i)
+		files = append(files, filepath.Join(dir, name))
+		if i != 10 {
+			fsys[name] = &fstest.MapFile{Data: []byte("# page")}
+		}
+	}
+
+	_, err := Build(context.Background(), Options{Root: files[0], ScanDir: dir, FS: fsys, Files: files, Extensions: []string{".md"}, MaxWorkers: 2})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+}
+
+func TestBuild_FollowSymlinksCanonicalNodes(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("eval temp dir: %v", err)
+	}
+	dir := filepath.Join(base, "docs")
+	root := filepath.Join(dir, "index.md")
+	page := filepath.Join(dir, "real", "page.md")
+	testutil.MustWrite(t, root, "[alias](alias/page.md) [real](real/page.md) [dir](alias/)")
+	testutil.MustWrite(t, page, "[home](../index.md)")
+	testutil.MustWrite(t, filepath.Join(dir, "real", "index.md"), "# section")
+	if err := os.Symlink(filepath.Join(dir, "real"), filepath.Join(dir, "alias")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	section := filepath.Join(dir, "real", "index.md")
+	viaAlias := filepath.Join(dir, "alias", "page.md")
+
+	g, err := Build(context.Background(), Options{
+		Root:           root,
+		ScanDir:        dir,
+		Files:          []string{root, viaAlias, section},
+		Extensions:     []string{".md"},
+		Candidates:     []string{"/index.md"},
+		FollowSymlinks: true,
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	want := map[string][]string{
+		root:    {section, page},
+		page:    {root},
+		section: {},
+	}
+	if !reflect.DeepEqual(g.Adjacency, want) {
+		t.Fatalf("unexpected adjacency\nwant: %#v\n got: %#v", want, g.Adjacency)
+	}
+	if len(g.Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %#v", g.Warnings)
+	}
+}
+
+func TestBuild_WarningsAndEdgesCarryLinkPositions(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	a := filepath.Join(dir, "a.md")
+	testutil.MustWrite(t, root, "# Index\n\nSee [a](./a.md).\n\n  Then [gone](./missing.md) and [again](missing.md).\n")
+	testutil.MustWrite(t, a, "# a")
+
+	g, err := Build(context.Background(), Options{
+		Root:       root,
+		ScanDir:    dir,
+		Files:      []string{root, a},
+		Extensions: []string{".md"},
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	edges := g.Edges[root]
+	if len(edges) != 1 || edges[0].Target != a || edges[0].Link.Line != 3 || edges[0].Link.Column != 5 {
+		t.Fatalf("unexpected edges: %#v", edges)
+	}
+
+	if len(g.Warnings) != 2 {
+		t.Fatalf("expected one warning per unresolved link occurrence, got: %#v", g.Warnings)
+	}
+	first := g.Warnings[0]
+	if first.Kind != WarningUnresolvedLink || first.Source != root || first.Link.Line != 5 || first.Link.Column != 8 {
+		t.Fatalf("unexpected first warning: %#v", first)
+	}
+	if got := first.Format(dir); got != "unresolved local markdown link: index.md:5:8 -> ./missing.md" {
+		t.Fatalf("unexpected formatted warning: %s", got)
+	}
+	if g.Warnings[1].Link.Column != 33 {
+		t.Fatalf("unexpected second warning column: %#v", g.Warnings[1])
+	}
+}
+
+func TestBuildAndAnalyze_AssetOrphans(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	page := filepath.Join(dir, "page.md")
+	orphan := filepath.Join(dir, "orphan.md")
+	logo := filepath.Join(dir, "img", "logo.png")
+	diagram := filepath.Join(dir, "img", "diagram.svg")
+	unused := filepath.Join(dir, "img", "unused.png")
+	testutil.MustWrite(t, root, "![logo](./img/logo.png)\n[page](./page.md)\n![gone](./img/gone.png)\n[notes](./notes.txt)")
+	testutil.MustWrite(t, page, `<img src="img/diagram.svg">`)
+	testutil.MustWrite(t, orphan, "![unused](./img/unused.png)")
+	for _, asset := range []string{logo, diagram, unused} {
+		testutil.MustWrite(t, asset, "asset")
+	}
+
+	files := []string{root, page, orphan}
+	g, err := Build(context.Background(), Options{
+		Root:          root,
+		ScanDir:       dir,
+		Files:         files,
+		Extensions:    []string{".md"},
+		Assets:        []string{diagram, logo, unused},
+		AssetPatterns: []string{"*.png", "*.svg"},
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if !reflect.DeepEqual(g.Adjacency[root], []string{logo, page}) {
+		t.Fatalf("unexpected root adjacency: %#v", g.Adjacency[root])
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Kind != WarningUnresolvedAsset {
+		t.Fatalf("expected one unresolved asset warning, got: %#v", g.Warnings)
+	}
+
+	analysis, err := Analyze(g, []string{dir}, files)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if !reflect.DeepEqual(analysis.OrphanAssetsRelative, []string{"img/unused.png"}) {
+		t.Fatalf("unexpected orphan assets: %#v", analysis.OrphanAssetsRelative)
+	}
+	if !reflect.DeepEqual(analysis.Reachable, []string{root, page}) {
+		t.Fatalf("expected reachable pages only, got: %#v", analysis.Reachable)
+	}
+}
+
+func TestBuild_ResolvesExtensionlessAndDirectoryLinks(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	setup := filepath.Join(dir, "setup.md")
+	setupIndex := filepath.Join(dir, "setup", "index.md")
+	apiReadme := filepath.Join(dir, "api", "README.md")
+	guide := filepath.Join(dir, "guide", "_index.md")
+	back := filepath.Join(dir, "guide", "back.md")
+
+	testutil.MustWrite(t, root, "[Setup](./setup) [API](./api/) [Guide](guide) [Script](./run) [Empty](./empty/) [Gone](./gone)")
+	testutil.MustWrite(t, setup, "# setup")
+	testutil.MustWrite(t, setupIndex, "# shadowed by setup.md")
+	testutil.MustWrite(t, apiReadme, "# api")
+	testutil.MustWrite(t, guide, "[Back](..) [Here](./) [Back again](./back)")
+	testutil.MustWrite(t, back, "# back")
+	testutil.MustWrite(t, filepath.Join(dir, "run"), "#!/bin/sh")
+	testutil.MustWrite(t, filepath.Join(dir, "empty", "notes.txt"), "no index")
+
+	files := []string{root, setup, setupIndex, apiReadme, guide, back}
+	g, err := Build(context.Background(), Options{
+		Root:       root,
+		ScanDir:    dir,
+		Files:      files,
+		Extensions: []string{".md"},
+		Candidates: []string{".md", "/index.md", "/README.md", "/_index.md"},
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	wantAdj := map[string][]string{
+		root:       {apiReadme, guide, setup},
+		setup:      {},
+		setupIndex: {},
+		apiReadme:  {},
+		guide:      {guide, back, root},
+		back:       {},
+	}
+	if !reflect.DeepEqual(g.Adjacency, wantAdj) {
+		t.Fatalf("unexpected adjacency\nwant: %#v\n got: %#v", wantAdj, g.Adjacency)
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Link.Destination != "./gone" || g.Warnings[0].Target != filepath.Join(dir, "gone") {
+		t.Fatalf("expected one unresolved warning for ./gone, got: %#v", g.Warnings)
+	}
+
+	g, err = Build(context.Background(), Options{Root: root, ScanDir: dir, Files: files, Extensions: []string{".md"}})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if len(g.Adjacency[root]) != 0 || len(g.Warnings) != 0 {
+		t.Fatalf("expected extensionless links to be ignored without candidates, got: %#v %#v", g.Adjacency[root], g.Warnings)
+	}
+}
+
+func TestBuild_BrokenFragments(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	guide := filepath.Join(dir, "guide.md")
+	testutil.MustWrite(t, root, "# Index\n\n[ok](./guide.md#installation) [renamed](./guide.md#setup) [self](#index) [gone](#nope) [top](guide.md#top)\n")
+	testutil.MustWrite(t, guide, "# Guide\n\n## Installation\n\n## Usage {#how-to}\n\n[usage](#how-to) [legacy](#old)\n\n<a id=\"old\"></a>\n")
+
+	opts := Options{
+		Root:        root,
+		ScanDir:     dir,
+		Files:       []string{root, guide},
+		Extensions:  []string{".md"},
+		AnchorStyle: slug.GitHub,
+	}
+	g, err := Build(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	var got []string
+	for _, warning := range g.Warnings {
+		if warning.Kind != WarningBrokenFragment {
+			t.Fatalf("unexpected warning kind: %#v", warning)
+		}
+		got = append(got, warning.Format(dir))
+	}
+	want := []string{
+		"broken link fragment: index.md:3:31 -> ./guide.md#setup",
+		"broken link fragment: index.md:3:74 -> #nope",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected warnings\nwant: %#v\n got: %#v", want, got)
+	}
+	if !reflect.DeepEqual(g.Adjacency[root], []string{guide}) {
+		t.Fatalf("fragment links should still produce edges, got: %#v", g.Adjacency[root])
+	}
+
+	opts.AnchorStyle = ""
+	g, err = Build(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if len(g.Warnings) != 0 {
+		t.Fatalf("expected no fragment checks without an anchor style, got: %#v", g.Warnings)
+	}
+}
+
+func TestBuild_ShortestPathWikiLinks(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	unique := filepath.Join(dir, "guide", "Unique.md")
+	shared := filepath.Join(dir, "notes", "Shared.md")
+	archived := filepath.Join(dir, "archive", "Shared.md")
+	todo := filepath.Join(dir, "notes", "todo.md")
+	diagram := filepath.Join(dir, "img", "diagram.png")
+
+	testutil.MustWrite(t, root, "[[unique]] [[Shared]] [[notes/Shared]] [[Missing]] ![[diagram.png]]")
+	testutil.MustWrite(t, unique, "# unique")
+	testutil.MustWrite(t, shared, "# shared")
+	testutil.MustWrite(t, archived, "# archived")
+	testutil.MustWrite(t, todo, "[[Shared]]")
+	testutil.MustWrite(t, diagram, "png")
+
+	files := []string{root, unique, shared, archived, todo}
+	g, err := Build(context.Background(), Options{
+		Root:          root,
+		ScanDir:       dir,
+		Files:         files,
+		Extensions:    []string{".md"},
+		Assets:        []string{diagram},
+		AssetPatterns: []string{"*.png"},
+		WikiLinks:     WikiLinkShortest,
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	if want := []string{unique, diagram, shared}; !reflect.DeepEqual(g.Adjacency[root], want) {
+		t.Fatalf("unexpected root targets\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+	}
+	if want := []string{shared}; !reflect.DeepEqual(g.Adjacency[todo], want) {
+		t.Fatalf("expected path fallback for ambiguous link\nwant: %#v\n got: %#v", want, g.Adjacency[todo])
+	}
+
+	var got []string
+	for _, warning := range g.Warnings {
+		got = append(got, warning.Format(dir))
+	}
+	want := []string{
+		"ambiguous wikilink: index.md:1:12 -> Shared (matches archive/Shared.md, notes/Shared.md)",
+		"unresolved local markdown link: index.md:1:40 -> Missing",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected warnings\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestBuild_FrontMatterAliasesDraftsAndOrphanPolicy(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	guide := filepath.Join(dir, "notes", "guide.md")
+	draft := filepath.Join(dir, "draft.md")
+	allowed := filepath.Join(dir, "allowed.md")
+	hidden := filepath.Join(dir, "hidden.md")
+
+	testutil.MustWrite(t, root, "[[Setup Guide]] [draft](./draft.md)")
+	testutil.MustWrite(t, guide, "---\naliases: [Setup Guide]\n---\n# guide")
+	testutil.MustWrite(t, draft, "---\ndraft: true\n---\n[hidden](./hidden.md)")
+	testutil.MustWrite(t, allowed, "+++\n[gorphan]\norphan = \"allowed\"\n+++\n")
+	testutil.MustWrite(t, hidden, "# hidden")
+
+	files := []string{root, guide, draft, allowed, hidden}
+	build := func(mode DraftMode) (*Graph, *Analysis) {
+		t.Helper()
+		g, err := Build(context.Background(), Options{Root: root, ScanDir: dir, Files: files, Extensions: []string{".md"}, Drafts: mode})
+		if err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+		a, err := Analyze(g, []string{dir}, files)
+		if err != nil {
+			t.Fatalf("analyze failed: %v", err)
+		}
+		return g, a
+	}
+
+	g, a := build(DraftsInclude)
+	if want := []string{draft, guide}; !reflect.DeepEqual(g.Adjacency[root], want) {
+		t.Fatalf("expected alias to resolve\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+	}
+	if len(a.Orphans) != 0 {
+		t.Fatalf("expected allowed page to be exempt, got orphans %#v", a.Orphans)
+	}
+
+	g, a = build(DraftsNoLinks)
+	if len(g.Adjacency[draft]) != 0 {
+		t.Fatalf("expected draft links to be ignored, got %#v", g.Adjacency[draft])
+	}
+	if want := []string{"hidden.md"}; !reflect.DeepEqual(a.OrphansRelative, want) {
+		t.Fatalf("unexpected orphans\nwant: %#v\n got: %#v", want, a.OrphansRelative)
+	}
+
+	g, a = build(DraftsExclude)
+	if want := []string{draft}; !reflect.DeepEqual(g.Excluded, want) {
+		t.Fatalf("unexpected excluded drafts\nwant: %#v\n got: %#v", want, g.Excluded)
+	}
+	if _, ok := g.Adjacency[draft]; ok || len(g.Warnings) != 0 {
+		t.Fatalf("expected draft to be dropped silently, got adjacency %#v warnings %#v", g.Adjacency, g.Warnings)
+	}
+	if want := []string{"hidden.md"}; !reflect.DeepEqual(a.OrphansRelative, want) {
+		t.Fatalf("unexpected orphans\nwant: %#v\n got: %#v", want, a.OrphansRelative)
+	}
+}
+
+func TestBuild_MixedFormatsUseExtractorsByExtension(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	guide := filepath.Join(dir, "guide.rst")
+	install := filepath.Join(dir, "install.rst")
+	manual := filepath.Join(dir, "manual.adoc")
+	partial := filepath.Join(dir, "partial.adoc")
+	orphan := filepath.Join(dir, "orphan.rst")
+
+	testutil.MustWrite(t, root, "[guide](./guide.rst)")
+	testutil.MustWrite(t, guide, "Guide\n=====\n\nSee :doc:`install` and `the manual <manual.adoc#_usage>`_.\n")
+	testutil.MustWrite(t, install, "Install\n=======\n")
+	testutil.MustWrite(t, manual, "= Manual\n\n== Usage\n\ninclude::partial.adoc[]\nxref:guide.rst#missing[]\n")
+	testutil.MustWrite(t, partial, "text")
+	testutil.MustWrite(t, orphan, "Orphan\n======\n")
+
+	files := []string{root, guide, install, manual, partial, orphan}
+	g, err := Build(context.Background(), Options{
+		Root:        root,
+		ScanDir:     dir,
+		Files:       files,
+		Extensions:  []string{".md", ".rst", ".adoc"},
+		AnchorStyle: slug.GitHub,
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	analysis, err := Analyze(g, []string{dir}, files)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if want := []string{"orphan.rst"}; !reflect.DeepEqual(analysis.OrphansRelative, want) {
+		t.Fatalf("unexpected orphans\nwant: %#v\n got: %#v", want, analysis.OrphansRelative)
+	}
+
+	var got []string
+	for _, warning := range g.Warnings {
+		got = append(got, warning.Format(dir))
+	}
+	want := []string{"broken link fragment: manual.adoc:6:6 -> guide.rst#missing"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected warnings\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestBuild_HugoRefsAndSections(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "_index.md")
+	install := filepath.Join(dir, "guide", "install.md")
+	guideIndex := filepath.Join(dir, "guide", "_index.md")
+	nested := filepath.Join(dir, "guide", "advanced", "tuning.md")
+	bundle := filepath.Join(dir, "posts", "hello", "index.md")
+	resource := filepath.Join(dir, "posts", "hello", "notes.md")
+	about := filepath.Join(dir, "about.md")
+	dupA := filepath.Join(dir, "a", "faq.md")
+	dupB := filepath.Join(dir, "b", "faq.md")
+
+	testutil.MustWrite(t, root, "{{< ref \"/guide\" >}} {{< relref \"install\" >}} {{< ref \"posts/hello\" >}} {{< ref \"faq\" >}} {{< ref \"missing\" >}}")
+	testutil.MustWrite(t, install, "# install")
+	testutil.MustWrite(t, guideIndex, "# guide")
+	testutil.MustWrite(t, nested, "# tuning")
+	testutil.MustWrite(t, bundle, "# hello")
+	testutil.MustWrite(t, resource, "notes")
+	testutil.MustWrite(t, about, "# about")
+	testutil.MustWrite(t, dupA, "# a")
+	testutil.MustWrite(t, dupB, "# b")
+
+	files := []string{root, install, guideIndex, nested, bundle, resource, about, dupA, dupB}
+	build := func(mode HugoMode) *Graph {
+		t.Helper()
+		g, err := Build(context.Background(), Options{Root: root, ScanDir: dir, Files: files, Extensions: []string{".md"}, Candidates: []string{".md"}, Hugo: mode})
+		if err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+		return g
+	}
+
+	g := build(HugoRefs)
+	if want := []string{guideIndex, install, bundle}; !reflect.DeepEqual(g.Adjacency[root], want) {
+		t.Fatalf("unexpected ref targets\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+	}
+	var got []string
+	for _, warning := range g.Warnings {
+		got = append(got, warning.Format(dir))
+	}
+	want := []string{
+		"ambiguous ref: _index.md:1:82 -> faq (matches a/faq.md, b/faq.md)",
+		"unresolved local markdown link: _index.md:1:100 -> missing",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected warnings\nwant: %#v\n got: %#v", want, got)
+	}
+
+	g = build(HugoSections)
+	if want := []string{dupA, about, dupB, guideIndex, install, bundle}; !reflect.DeepEqual(g.Adjacency[root], want) {
+		t.Fatalf("unexpected root section targets\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+	}
+	if want := []string{nested, install}; !reflect.DeepEqual(g.Adjacency[guideIndex], want) {
+		t.Fatalf("unexpected guide section targets\nwant: %#v\n got: %#v", want, g.Adjacency[guideIndex])
+	}
+}
+
+func TestBuild_JekyllLiquidTagsAndPermalinks(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	setup := filepath.Join(dir, "_docs", "setup.md")
+	post := filepath.Join(dir, "_posts", "2021-01-01-hello.md")
+	snippet := filepath.Join(dir, "snippets", "note.md")
+	callout := filepath.Join(dir, "_includes", "callout.md")
+	about := filepath.Join(dir, "pages", "about.md")
+	team := filepath.Join(dir, "pages", "team.md")
+
+	testutil.MustWrite(t, root, "{% link _docs/setup.md %} {% post_url 2021-01-01-hello %} {% include_relative snippets/note.md %} {% include callout.md %} [about](/about/) {% post_url missing %}")
+	testutil.MustWrite(t, setup, "---\npermalink: /docs/setup/\n---\n[team](../../team.html)")
+	testutil.MustWrite(t, post, "# hello")
+	testutil.MustWrite(t, snippet, "note")
+	testutil.MustWrite(t, callout, "callout")
+	testutil.MustWrite(t, about, "---\npermalink: /about/\n---\n")
+	testutil.MustWrite(t, team, "---\npermalink: /team.html\n---\n")
+
+	files := []string{root, setup, post, snippet, callout, about, team}
+	g, err := Build(context.Background(), Options{Root: root, ScanDir: dir, Files: files, Extensions: []string{".md"}, Candidates: []string{".md"}, Jekyll: true})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if want := []string{setup, callout, post, about, snippet}; !reflect.DeepEqual(g.Adjacency[root], want) {
+		t.Fatalf("unexpected root targets\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+	}
+	if want := []string{team}; !reflect.DeepEqual(g.Adjacency[setup], want) {
+		t.Fatalf("expected relative permalink link to resolve\nwant: %#v\n got: %#v", want, g.Adjacency[setup])
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Link.Destination != "missing" {
+		t.Fatalf("expected unresolved post_url warning, got %#v", g.Warnings)
+	}
+}
+
+func TestBuildAndAnalyze_NavEntriesAreRoots(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	guide := filepath.Join(dir, "guide.md")
+	linked := filepath.Join(dir, "linked.md")
+	orphan := filepath.Join(dir, "orphan.md")
+	testutil.MustWrite(t, root, "# home")
+	testutil.MustWrite(t, guide, "[linked](linked.md)")
+	testutil.MustWrite(t, linked, "# linked")
+	testutil.MustWrite(t, orphan, "# orphan")
+
+	config := filepath.Join(dir, "mkdocs.yml")
+	entries := []nav.Entry{
+		{Source: config, Destination: "index.md", Path: root, Line: 2, Column: 5},
+		{Source: config, Title: "Guide", Destination: "guide.md", Path: guide, Line: 3, Column: 12},
+		{Source: config, Title: "Gone", Destination: "gone.md", Path: filepath.Join(dir, "gone.md"), Line: 4, Column: 11},
+	}
+	files := []string{root, guide, linked, orphan}
+	g, err := Build(context.Background(), Options{Root: root, ScanDir: dir, Files: files, Extensions: []string{".md"}, Nav: entries})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if want := []string{root, guide}; !reflect.DeepEqual(g.NavRoots, want) {
+		t.Fatalf("unexpected nav roots\nwant: %#v\n got: %#v", want, g.NavRoots)
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Kind != WarningUnresolvedNav {
+		t.Fatalf("expected unresolved nav warning, got %#v", g.Warnings)
+	}
+	if got, want := g.Warnings[0].Format(dir), "unresolved nav entry: mkdocs.yml:4:11 -> gone.md"; got != want {
+		t.Fatalf("unexpected warning text\nwant: %s\n got: %s", want, got)
+	}
+
+	analysis, err := Analyze(g, []string{dir}, files)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if want := []string{orphan}; !reflect.DeepEqual(analysis.Orphans, want) {
+		t.Fatalf("unexpected orphans\nwant: %#v\n got: %#v", want, analysis.Orphans)
+	}
+}
+
+func TestAnalyze_NavOnlyKeepsUnlistedPagesOrphaned(t *testing.T) {
+	dir := t.TempDir()
+	summary := filepath.Join(dir, "SUMMARY.md")
+	intro := filepath.Join(dir, "intro.md")
+	unlisted := filepath.Join(dir, "unlisted.md")
+	image := filepath.Join(dir, "diagram.png")
+	hidden := filepath.Join(dir, "hidden.png")
+	testutil.MustWrite(t, summary, "[Intro](intro.md)\n[Next]()\n")
+	testutil.MustWrite(t, intro, "[more](unlisted.md) ![d](diagram.png)")
+	testutil.MustWrite(t, unlisted, "![h](hidden.png)")
+	testutil.MustWrite(t, image, "png")
+	testutil.MustWrite(t, hidden, "png")
+
+	entries := []nav.Entry{
+		{Source: summary, Title: "Intro", Destination: "intro.md", Path: intro, Line: 1, Column: 9},
+		{Source: summary, Title: "Next", Line: 2, Column: 1, Draft: true},
+	}
+	files := []string{summary, intro, unlisted}
+	g, err := Build(context.Background(), Options{Root: summary, ScanDir: dir, Files: files, Extensions: []string{".md"}, Assets: []string{image, hidden}, AssetPatterns: []string{"*.png"}, Nav: entries, NavOnly: true})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Format(dir) != "draft chapter without file: SUMMARY.md:2:1 -> Next" {
+		t.Fatalf("expected draft chapter warning, got %#v", g.Warnings)
+	}
+
+	analysis, err := Analyze(g, []string{dir}, files)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if want := []string{unlisted}; !reflect.DeepEqual(analysis.Orphans, want) {
+		t.Fatalf("unexpected orphans\nwant: %#v\n got: %#v", want, analysis.Orphans)
+	}
+	if want := []string{hidden}; !reflect.DeepEqual(analysis.OrphanAssets, want) {
+		t.Fatalf("unexpected orphan assets\nwant: %#v\n got: %#v", want, analysis.OrphanAssets)
+	}
+}
+
+func TestBuild_MySTToctreesAndRoles(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	install := filepath.Join(dir, "install.md")
+	first := filepath.Join(dir, "tutorials", "first.md")
+	second := filepath.Join(dir, "tutorials", "second.rst")
+	api := filepath.Join(dir, "reference", "api.md")
+	labeled := filepath.Join(dir, "reference", "labels.md")
+
+	testutil.MustWrite(t, root, "```{toctree}\n:glob:\ninstall\ntutorials/*\nmissing\n```\n")
+	testutil.MustWrite(t, install, "{doc}`/reference/api` {ref}`Labels <Config-Keys>`")
+	testutil.MustWrite(t, first, "# first")
+	testutil.MustWrite(t, second, "second\n======\n")
+	testutil.MustWrite(t, api, "# api")
+	testutil.MustWrite(t, labeled, "(config-keys)=\n# Keys\n")
+
+	files := []string{root, install, first, second, api, labeled}
+	g, err := Build(context.Background(), Options{Root: root, ScanDir: dir, Files: files, Extensions: []string{".md", ".rst"}, MyST: true})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if want := []string{install, first, second}; !reflect.DeepEqual(g.Adjacency[root], want) {
+		t.Fatalf("unexpected toctree targets\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+	}
+	if want := []string{api, labeled}; !reflect.DeepEqual(g.Adjacency[install], want) {
+		t.Fatalf("unexpected role targets\nwant: %#v\n got: %#v", want, g.Adjacency[install])
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Link.Destination != "missing" {
+		t.Fatalf("expected unresolved toctree entry warning, got %#v", g.Warnings)
+	}
+}
+
+func TestBuild_IncludeDirectivesResolveFromBaseDirs(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "guide", "index.md")
+	local := filepath.Join(dir, "guide", "local.md")
+	shared := filepath.Join(dir, "snippets", "shared.md")
+
+	testutil.MustWrite(t, root, "--8<-- \"local.md\"\n\n{!snippets/shared.md!}\n\n<!-- include: gone.md -->\n")
+	testutil.MustWrite(t, local, "local")
+	testutil.MustWrite(t, shared, "shared")
+
+	includes, err := parser.ParseIncludePatterns([]string{"snippets", "markdown-include", "comment"})
+	if err != nil {
+		t.Fatalf("parse include patterns: %v", err)
+	}
+	files := []string{root, local, shared}
+	g, err := Build(context.Background(), Options{
+		Root:       root,
+		ScanDir:    dir,
+		Files:      files,
+		Extensions: []string{".md"},
+		Extractors: parser.DefaultExtractors(parser.Options{Includes: includes}),
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if want := []string{local, shared}; !reflect.DeepEqual(g.Adjacency[root], want) {
+		t.Fatalf("unexpected include targets\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+	}
+	for _, edge := range g.Edges[root] {
+		if edge.Link.Kind != parser.LinkInclude {
+			t.Fatalf("expected include edge kind, got %q", edge.Link.Kind)
+		}
+	}
+	if len(g.Warnings) != 1 || g.Warnings[0].Target != filepath.Join(dir, "guide", "gone.md") {
+		t.Fatalf("expected unresolved include warning, got %#v", g.Warnings)
+	}
+}
+
+func TestBuild_WikiEmbedsHeadingAndBlockRefs(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "index.md")
+	note := filepath.Join(dir, "notes", "note.md")
+	embedded := filepath.Join(dir, "notes", "embedded.md")
+	testutil.MustWrite(t, root, "![[embedded]] [[note#setup  linux]] [[note#Gone]] [[note^para]] [[note#^missing]] [[#Index]] [[#^top-block]] [gone](#nope)\n\n# Index\n\nTop ^top-block\n")
+	testutil.MustWrite(t, note, "# Note\n\n## Setup: Linux\n\nSome text ^para\n")
+	testutil.MustWrite(t, embedded, "# embedded")
+
+	wantWiki := []string{
+		"broken link fragment: index.md:1:37 -> note#Gone",
+		"broken link fragment: index.md:1:65 -> note#^missing",
+	}
+	// Wikilink heading and block refs are checked with or without a slug
+	// style; the markdown #fragment link only with one.
+	for style, want := range map[slug.Style][]string{
+		"":          wantWiki,
+		slug.GitHub: append(append([]string(nil), wantWiki...), "broken link fragment: index.md:1:110 -> #nope"),
+	} {
+		g, err := Build(context.Background(), Options{
+			Root:        root,
+			ScanDir:     dir,
+			Files:       []string{root, note, embedded},
+			Extensions:  []string{".md"},
+			AnchorStyle: style,
+			WikiLinks:   WikiLinkShortest,
+		})
+		if err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+
+		if want := []string{embedded, note}; !reflect.DeepEqual(g.Adjacency[root], want) {
+			t.Fatalf("unexpected root targets\nwant: %#v\n got: %#v", want, g.Adjacency[root])
+		}
+		var got []string
+		for _, warning := range g.Warnings {
+			got = append(got, warning.Format(dir))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("style %q: unexpected warnings\nwant: %#v\n got: %#v", style, want, got)
+		}
+	}
+}
+
 func TestBuild_RequiresRootAndDir(t *testing.T) {
-	_, err := Build(Options{})
+	_, err := Build(context.Background(), Options{})
 	if err == nil {
 		t.Fatalf("expected error for empty options")
 	}
@@ -76,7 +833,7 @@ func TestAnalyze_ReachableAndOrphans(t *testing.T) {
 		},
 	}
 
-	analysis, err := Analyze(g, dir, []string{orphan, b, root, a})
+	analysis, err := Analyze(g, []string{dir}, []string{orphan, b, root, a})
 	if err != nil {
 		t.Fatalf("analyze failed: %v", err)
 	}
@@ -109,7 +866,7 @@ func TestAnalyze_RootNotInInventory_ReturnsError(t *testing.T) {
 		Adjacency: map[string][]string{root: {}},
 	}
 
-	_, err := Analyze(g, dir, []string{other})
+	_, err := Analyze(g, []string{dir}, []string{other})
 	if err == nil || !strings.Contains(err.Error(), "root markdown file is not in scan result") {
 		t.Fatalf("expected root inventory error, got: %v", err)
 	}
@@ -127,7 +884,7 @@ func TestAnalyze_HandlesRootWithNoEdges(t *testing.T) {
 		Adjacency: map[string][]string{root: {}},
 	}
 
-	analysis, err := Analyze(g, dir, []string{root, orphan})
+	analysis, err := Analyze(g, []string{dir}, []string{root, orphan})
 	if err != nil {
 		t.Fatalf("analyze failed: %v", err)
 	}
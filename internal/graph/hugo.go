@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+)
+
+type HugoMode string
+
+const (
+	HugoNone     HugoMode = "none"
+	HugoRefs     HugoMode = "refs"
+	HugoSections HugoMode = "sections"
+)
+
+func (m HugoMode) resolvesRefs() bool {
+	return m == HugoRefs || m == HugoSections
+}
+
+// resolveHugoRef applies Hugo's ref/relref lookup: a leading "/" is relative
+// to the content directory (--dir), other paths are tried against the page's
+// directory and then the content directory, and bare names fall back to a
+// site-wide file name match. Extensionless paths also match section
+// (_index) and leaf bundle (index) pages. Ambiguous names return every
+// candidate instead.
+func (s *buildState) resolveHugoRef(srcDir, target string) (string, []string) {
+	clean := strings.TrimPrefix(target, "/")
+	bases := []string{filepath.Join(srcDir, filepath.FromSlash(clean)), filepath.Join(s.scanDirAbs, filepath.FromSlash(clean))}
+	if strings.HasPrefix(target, "/") {
+		bases = bases[1:]
+	}
+	for _, base := range bases {
+		if page, ok := s.hugoPage(base); ok {
+			return page, nil
+		}
+	}
+	if strings.Contains(strings.TrimSuffix(clean, "/"), "/") {
+		return "", nil
+	}
+
+	names := []string{clean}
+	if filepath.Ext(clean) == "" {
+		names = names[:0]
+		for _, ext := range sortedKeys(s.extSet) {
+			names = append(names, clean+ext)
+		}
+	}
+	matches := make([]string, 0, 1)
+	for _, name := range names {
+		for _, node := range s.wikiIndex[strings.ToLower(name)] {
+			if _, ok := s.inventory[node]; ok {
+				matches = append(matches, node)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	}
+	return "", matches
+}
+
+func (s *buildState) hugoPage(base string) (string, bool) {
+	candidates := []string{filepath.Clean(base)}
+	if filepath.Ext(base) == "" {
+		candidates = candidates[:0]
+		for _, ext := range sortedKeys(s.extSet) {
+			candidates = append(candidates, base+ext, filepath.Join(base, "_index"+ext), filepath.Join(base, "index"+ext))
+		}
+	}
+	for _, candidate := range candidates {
+		if _, ok := s.inventory[candidate]; ok {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// buildHugoSections maps each section's _index page to the pages it owns:
+// regular pages, leaf bundles and child sections up to the next nested
+// section. Pages next to a leaf bundle's index are bundle resources, not
+// pages, and are left out.
+func (s *buildState) buildHugoSections() map[string][]string {
+	sections := make(map[string]string)
+	bundles := make(map[string]struct{})
+	for _, src := range s.sources {
+		switch hugoPageName(src) {
+		case "_index":
+			sections[filepath.Dir(src)] = src
+		case "index":
+			bundles[filepath.Dir(src)] = struct{}{}
+		}
+	}
+
+	children := make(map[string][]string, len(sections))
+	for _, src := range s.sources {
+		dir := filepath.Dir(src)
+		if name := hugoPageName(src); name == "_index" || name == "index" {
+			dir = filepath.Dir(dir)
+		} else if _, ok := bundles[dir]; ok {
+			continue
+		}
+		for pathutil.IsWithinDir(s.scanDirAbs, dir) {
+			if owner, ok := sections[dir]; ok {
+				children[owner] = append(children[owner], src)
+				break
+			}
+			if dir == s.scanDirAbs {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return children
+}
+
+func hugoPageName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func sectionEdges(children []string) []Edge {
+	edges := make([]Edge, 0, len(children))
+	for _, child := range children {
+		edges = append(edges, Edge{Target: child, Link: parser.Link{Kind: parser.LinkSection}})
+	}
+	return edges
+}
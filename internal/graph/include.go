@@ -0,0 +1,31 @@
+package graph
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+)
+
+// resolveIncludeDirective resolves paths named by include directives.
+// Snippets and markdown-include read paths from a base directory rather than
+// the including page, so the page directory, --dir and --site-root are tried
+// in that order; a leading "/" skips the page directory. reStructuredText
+// and AsciiDoc includes are plain relative links and are not handled here.
+func (s *buildState) resolveIncludeDirective(srcDir string, link parser.Link) (string, bool) {
+	if link.Kind != parser.LinkInclude || link.Text == "" {
+		return "", false
+	}
+	target := filepath.FromSlash(strings.TrimPrefix(link.Target, "/"))
+	bases := []string{srcDir, s.scanDirAbs, s.siteRootAbs}
+	if strings.HasPrefix(link.Target, "/") {
+		bases = bases[1:]
+	}
+	for _, base := range bases {
+		candidate := filepath.Join(base, target)
+		if s.isNode(candidate) || s.pathExists(candidate) {
+			return candidate, true
+		}
+	}
+	return filepath.Join(bases[0], target), true
+}
@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+)
+
+// indexJekyll records front matter permalinks and _posts entries so Liquid
+// post_url tags and links to rendered URLs can find their source files.
+func (s *buildState) indexJekyll() {
+	s.permalinks = make(map[string]string)
+	s.posts = make(map[string][]string)
+	for _, src := range s.sources {
+		if permalink := s.pages[src].frontMatter.Permalink; permalink != "" && !strings.Contains(permalink, ":") {
+			s.permalinks[permalinkKey(permalink)] = src
+		}
+		rel, err := pathutil.RelativeSlash(s.scanDirAbs, src)
+		if err != nil {
+			continue
+		}
+		if i := strings.LastIndex("/"+rel, "/_posts/"); i >= 0 {
+			name := strings.TrimSuffix(rel[i+len("_posts/"):], path.Ext(rel))
+			s.posts[name] = append(s.posts[name], src)
+			if base := path.Base(name); base != name {
+				s.posts[base] = append(s.posts[base], src)
+			}
+		}
+	}
+}
+
+// resolveJekyllLink resolves Liquid tags against the directory each one is
+// defined for ({% link %} from --dir, {% include %} from _includes,
+// {% include_relative %} from the page) and maps links to rendered URLs back
+// to the page declaring that permalink. handled reports whether the link was
+// Jekyll's to resolve; an empty path then means it matched nothing.
+func (s *buildState) resolveJekyllLink(src, srcDir string, link parser.Link) (resolved string, handled bool) {
+	target := filepath.FromSlash(strings.TrimPrefix(link.Target, "/"))
+	if link.Kind == parser.LinkLiquid {
+		switch link.Text {
+		case "include_relative":
+			return filepath.Join(srcDir, filepath.FromSlash(link.Target)), true
+		case "include":
+			return filepath.Join(s.scanDirAbs, "_includes", target), true
+		case "post_url":
+			if posts := s.posts[strings.TrimPrefix(link.Target, "/")]; len(posts) == 1 {
+				return posts[0], true
+			}
+			return "", true
+		}
+		return filepath.Join(s.scanDirAbs, target), true
+	}
+	if len(s.permalinks) == 0 || link.IsWiki() || s.isNode(s.linkPath(srcDir, link.Target)) {
+		return "", false
+	}
+	url := link.Target
+	if !strings.HasPrefix(url, "/") {
+		url = path.Join(path.Dir(s.pageURL(src)), url)
+	}
+	page, ok := s.permalinks[permalinkKey(url)]
+	return page, ok
+}
+
+func (s *buildState) pageURL(src string) string {
+	if permalink := s.pages[src].frontMatter.Permalink; permalink != "" && !strings.Contains(permalink, ":") {
+		return permalink
+	}
+	rel, err := pathutil.RelativeSlash(s.scanDirAbs, src)
+	if err != nil {
+		return "/"
+	}
+	return "/" + strings.TrimSuffix(rel, path.Ext(rel)) + ".html"
+}
+
+// permalinkKey compares URLs the way Jekyll serves them: "/a/", "/a",
+// "/a.html" and "/a/index.html" all name the same page.
+func permalinkKey(url string) string {
+	url = path.Clean("/" + url)
+	url = strings.TrimSuffix(url, ".html")
+	url = strings.TrimSuffix(url, "/index")
+	return strings.Trim(url, "/")
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+)
+
+func isSphinxLink(link parser.Link) bool {
+	return link.Kind == parser.LinkToctree || (link.Kind == parser.LinkRole && (link.Text == "doc" || link.Text == "ref"))
+}
+
+// indexLabels maps Sphinx labels, which are case-insensitive, to the pages
+// defining them.
+func (s *buildState) indexLabels() {
+	s.labels = make(map[string][]string)
+	for _, src := range s.sources {
+		for _, label := range s.pages[src].labels {
+			key := strings.ToLower(label)
+			if pages := s.labels[key]; len(pages) == 0 || pages[len(pages)-1] != src {
+				s.labels[key] = append(pages, src)
+			}
+		}
+	}
+}
+
+// resolveSphinxLink resolves toctree entries and {doc}/{ref} roles the way
+// Sphinx does: docnames are relative to the linking page, or to --dir (the
+// source directory) with a leading "/", and may omit the extension; glob
+// entries expand to every matching page but the linking one; {ref} names a
+// label defined anywhere. Labels defined on several pages return every
+// candidate instead.
+func (s *buildState) resolveSphinxLink(src string, link parser.Link) ([]string, []string) {
+	switch {
+	case link.IsLabelRef():
+		pages := s.labels[strings.ToLower(link.Target)]
+		if len(pages) > 1 {
+			return nil, pages
+		}
+		return pages, nil
+	case link.Text == "glob":
+		return s.sphinxGlob(src, link.Target), nil
+	}
+	base := s.docnameBase(src, link.Target)
+	candidates := []string{base}
+	for _, ext := range sortedKeys(s.extSet) {
+		candidates = append(candidates, base+ext)
+	}
+	for _, candidate := range candidates {
+		if _, ok := s.inventory[candidate]; ok {
+			return []string{candidate}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *buildState) docnameBase(src, docname string) string {
+	if strings.HasPrefix(docname, "/") {
+		return filepath.Join(s.scanDirAbs, filepath.FromSlash(strings.TrimPrefix(docname, "/")))
+	}
+	return filepath.Join(filepath.Dir(src), filepath.FromSlash(docname))
+}
+
+func (s *buildState) sphinxGlob(src, pattern string) []string {
+	relPattern, err := pathutil.RelativeSlash(s.scanDirAbs, s.docnameBase(src, pattern))
+	if err != nil {
+		return nil
+	}
+	matches := make([]string, 0)
+	for _, page := range s.sources {
+		if page == src {
+			continue
+		}
+		rel, err := pathutil.RelativeSlash(s.scanDirAbs, page)
+		if err != nil {
+			continue
+		}
+		docname, _ := path.Match(relPattern, strings.TrimSuffix(rel, path.Ext(rel)))
+		file, _ := path.Match(relPattern, rel)
+		if docname || file {
+			matches = append(matches, page)
+		}
+	}
+	return matches
+}
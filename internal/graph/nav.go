@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"path/filepath"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/nav"
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+)
+
+// navRoots returns the inventory pages listed by a navigation file. They are
+// traversed alongside the root, so pages reachable only through the nav are
+// not orphans. Draft entries and entries without a backing page become
+// warnings.
+func (s *buildState) navRoots(entries []nav.Entry) ([]string, []Warning) {
+	roots := make([]string, 0, len(entries))
+	warnings := make([]Warning, 0)
+	seen := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		link := parser.Link{
+			Kind:        parser.LinkNav,
+			Text:        entry.Title,
+			Destination: entry.Destination,
+			Line:        entry.Line,
+			Column:      entry.Column,
+		}
+		if entry.Draft {
+			warnings = append(warnings, Warning{Kind: WarningDraftNav, Source: entry.Source, Link: link})
+			continue
+		}
+		target := s.canonical(filepath.Clean(entry.Path))
+		if _, ok := s.inventory[target]; !ok {
+			// A nav file that is itself scanned already reports its broken links.
+			if _, scanned := s.inventory[entry.Source]; !scanned && !s.isExcluded(target) {
+				warnings = append(warnings, Warning{Kind: WarningUnresolvedNav, Source: entry.Source, Target: target, Link: link})
+			}
+			continue
+		}
+		if _, ok := seen[target]; ok || s.isExcluded(target) {
+			continue
+		}
+		seen[target] = struct{}{}
+		roots = append(roots, target)
+	}
+	return roots, warnings
+}
+
+// navOnlyAdjacency drops links into pages outside the nav roots. With an
+// authoritative nav such as SUMMARY.md, unlisted pages are not built, so they
+// stay orphans even when a listed page links to them; assets are unaffected.
+func navOnlyAdjacency(adjacency map[int][]int, pages map[int]struct{}, roots []int) map[int][]int {
+	listed := make(map[int]struct{}, len(roots))
+	for _, id := range roots {
+		listed[id] = struct{}{}
+	}
+	filtered := make(map[int][]int, len(adjacency))
+	for src, targets := range adjacency {
+		if _, ok := listed[src]; !ok {
+			if _, isPage := pages[src]; isPage {
+				continue
+			}
+		}
+		kept := make([]int, 0, len(targets))
+		for _, target := range targets {
+			_, isPage := pages[target]
+			if _, ok := listed[target]; ok || !isPage {
+				kept = append(kept, target)
+			}
+		}
+		filtered[src] = kept
+	}
+	return filtered
+}
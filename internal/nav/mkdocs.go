@@ -0,0 +1,143 @@
+package nav
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is a page listed by a navigation file. Listed pages are reachable
+// without any inbound markdown link. Draft entries name a page that has not
+// been written yet and have no Path.
+type Entry struct {
+	Source      string
+	Title       string
+	Destination string
+	Path        string
+	Line        int
+	Column      int
+	Draft       bool
+}
+
+// MkDocs is the part of an mkdocs.yml file that decides reachability.
+type MkDocs struct {
+	DocsDir string
+	Entries []Entry
+}
+
+// LoadMkDocs reads docs_dir and the nav tree of an mkdocs.yml file. Section
+// titles are flattened away; external URLs are skipped. Entry paths are
+// absolute, resolved against docs_dir (default "docs" next to the file).
+func LoadMkDocs(configPath string) (MkDocs, error) {
+	abs, err := filepath.Abs(configPath)
+	if err != nil {
+		return MkDocs{}, fmt.Errorf("resolve mkdocs config path: %w", err)
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return MkDocs{}, fmt.Errorf("read mkdocs config: %w", err)
+	}
+
+	site := MkDocs{DocsDir: filepath.Join(filepath.Dir(abs), "docs")}
+	inNav := false
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := stripYAMLComment(scanner.Text())
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			continue
+		}
+		topLevel := raw[0] != ' ' && raw[0] != '\t' && !strings.HasPrefix(trimmed, "-")
+		if topLevel {
+			key, value, _ := strings.Cut(trimmed, ":")
+			inNav = strings.TrimSpace(key) == "nav"
+			if strings.TrimSpace(key) == "docs_dir" {
+				if dir := unquoteYAML(value); dir != "" {
+					site.DocsDir = filepath.Join(filepath.Dir(abs), filepath.FromSlash(dir))
+				}
+			}
+			continue
+		}
+		if !inNav {
+			continue
+		}
+		item, ok := strings.CutPrefix(trimmed, "-")
+		if !ok {
+			continue
+		}
+		title, destination := splitNavItem(strings.TrimSpace(item))
+		if destination == "" || strings.Contains(destination, "://") || strings.HasPrefix(destination, "mailto:") {
+			continue
+		}
+		page := strings.TrimPrefix(destination, "/")
+		if strings.HasSuffix(page, "/") {
+			page = path.Join(page, "index.md")
+		}
+		site.Entries = append(site.Entries, Entry{
+			Source:      abs,
+			Title:       title,
+			Destination: destination,
+			Path:        filepath.Join(site.DocsDir, filepath.FromSlash(page)),
+			Line:        lineNo,
+			Column:      strings.Index(raw, destination) + 1,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return MkDocs{}, fmt.Errorf("read mkdocs config: %w", err)
+	}
+	return site, nil
+}
+
+// splitNavItem splits "Title: page.md" into its parts. A bare "page.md" has
+// no title, and "Section:" has no destination.
+func splitNavItem(item string) (string, string) {
+	key := item
+	rest := ""
+	if q := item[0]; q == '"' || q == '\'' {
+		end := strings.IndexByte(item[1:], q)
+		if end < 0 {
+			return "", unquoteYAML(item)
+		}
+		key, rest = item[:end+2], item[end+2:]
+		after, ok := strings.CutPrefix(strings.TrimSpace(rest), ":")
+		if !ok {
+			return "", unquoteYAML(key)
+		}
+		return unquoteYAML(key), unquoteYAML(after)
+	}
+	if i := strings.Index(item, ": "); i >= 0 {
+		return unquoteYAML(item[:i]), unquoteYAML(item[i+2:])
+	}
+	if strings.HasSuffix(item, ":") {
+		return unquoteYAML(strings.TrimSuffix(item, ":")), ""
+	}
+	return "", unquoteYAML(key)
+}
+
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return strings.TrimRight(line, " \t\r")
+}
+
+func unquoteYAML(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
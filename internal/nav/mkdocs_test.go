@@ -0,0 +1,72 @@
+package nav
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
+)
+
+func TestLoadMkDocs_NestedNav(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mkdocs.yml")
+	testutil.MustWrite(t, configPath, `site_name: Example # comment
+docs_dir: site
+nav:
+  - index.md
+  - 'User Guide':
+      - Install: guide/install.md
+      - "Usage: basics": "guide/usage.md"
+      - Advanced:
+          - guide/advanced.md # deep
+  - Reference: reference/
+  - GitHub: https://github.com/example/example
+theme: material
+`)
+
+	site, err := LoadMkDocs(configPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	docs := filepath.Join(dir, "site")
+	if site.DocsDir != docs {
+		t.Fatalf("unexpected docs dir: %s", site.DocsDir)
+	}
+
+	got := make([]string, 0, len(site.Entries))
+	for _, entry := range site.Entries {
+		got = append(got, entry.Title+"|"+entry.Path)
+	}
+	want := []string{
+		"|" + filepath.Join(docs, "index.md"),
+		"Install|" + filepath.Join(docs, "guide", "install.md"),
+		"Usage: basics|" + filepath.Join(docs, "guide", "usage.md"),
+		"|" + filepath.Join(docs, "guide", "advanced.md"),
+		"Reference|" + filepath.Join(docs, "reference", "index.md"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected entries\nwant: %#v\n got: %#v", want, got)
+	}
+	if entry := site.Entries[1]; entry.Line != 6 || entry.Column != 18 || entry.Destination != "guide/install.md" {
+		t.Fatalf("unexpected entry position: %#v", entry)
+	}
+}
+
+func TestLoadMkDocs_DefaultDocsDirAndMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mkdocs.yml")
+	testutil.MustWrite(t, configPath, "site_name: Example\n")
+
+	site, err := LoadMkDocs(configPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if site.DocsDir != filepath.Join(dir, "docs") || len(site.Entries) != 0 {
+		t.Fatalf("unexpected site: %#v", site)
+	}
+
+	if _, err := LoadMkDocs(filepath.Join(dir, "missing.yml")); err == nil {
+		t.Fatalf("expected error for missing config")
+	}
+}
@@ -0,0 +1,75 @@
+package nav
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var summaryChapter = regexp.MustCompile(`^\s*(?:[-*+]\s+)?\[((?:[^\]\\]|\\.)*)\]\(\s*([^)\s]*)\s*\)\s*$`)
+
+// LoadSummary reads the chapter list of an mdBook or GitBook SUMMARY.md.
+// Prefix, numbered and suffix chapters become entries in book order; part
+// titles, separators and text lines are structure only. Draft chapters
+// written as "[Title]()" are returned with Draft set and no Path. Entry
+// paths are absolute, resolved against the directory holding SUMMARY.md.
+func LoadSummary(summaryPath string) ([]Entry, error) {
+	abs, err := filepath.Abs(summaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve summary path: %w", err)
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("read summary: %w", err)
+	}
+
+	dir := filepath.Dir(abs)
+	entries := make([]Entry, 0)
+	fence := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			switch {
+			case fence == "":
+				fence = trimmed[:3]
+			case strings.HasPrefix(trimmed, fence):
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		m := summaryChapter.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		entry := Entry{
+			Source:      abs,
+			Title:       line[m[2]:m[3]],
+			Destination: line[m[4]:m[5]],
+			Line:        lineNo,
+			Column:      m[4] + 1,
+		}
+		page, _, _ := strings.Cut(entry.Destination, "#")
+		switch {
+		case entry.Destination == "":
+			entry.Draft = true
+			entry.Column = m[2]
+		case page == "" || strings.Contains(page, "://") || strings.HasPrefix(page, "mailto:"):
+			continue
+		default:
+			entry.Path = filepath.Join(dir, filepath.FromSlash(page))
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read summary: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,56 @@
+package nav
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
+)
+
+func TestLoadSummary_PartsSeparatorsAndDrafts(t *testing.T) {
+	dir := t.TempDir()
+	summaryPath := filepath.Join(dir, "SUMMARY.md")
+	testutil.MustWrite(t, summaryPath, `# Summary
+
+[Introduction](README.md)
+
+# User Guide
+
+- [Installation](guide/install.md)
+    - [Linux](guide/linux.md#setup)
+- [Future Work]()
+
+---
+
+* [GitHub](https://github.com/example/book)
+
+`+"```\n- [Example](not-a-chapter.md)\n```\n"+`
+[Contributors](misc/contributors.md)
+`)
+
+	entries, err := LoadSummary(summaryPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	got := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		got = append(got, entry.Title+"|"+entry.Path)
+	}
+	want := []string{
+		"Introduction|" + filepath.Join(dir, "README.md"),
+		"Installation|" + filepath.Join(dir, "guide", "install.md"),
+		"Linux|" + filepath.Join(dir, "guide", "linux.md"),
+		"Future Work|",
+		"Contributors|" + filepath.Join(dir, "misc", "contributors.md"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected entries\nwant: %#v\n got: %#v", want, got)
+	}
+	if draft := entries[3]; !draft.Draft || draft.Line != 9 || draft.Column != 3 {
+		t.Fatalf("unexpected draft entry: %#v", draft)
+	}
+	if entry := entries[1]; entry.Draft || entry.Line != 7 || entry.Column != 18 {
+		t.Fatalf("unexpected chapter position: %#v", entry)
+	}
+}
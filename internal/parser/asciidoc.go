@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	adocInclude     = regexp.MustCompile(`^include::([^\[\s]+)\[`)
+	adocXref        = regexp.MustCompile(`\bxref:([^\[\s]+)\[`)
+	adocCrossRef    = regexp.MustCompile(`<<([^,>\s]+)(?:,[^>]*)?>>`)
+	adocLinkMacro   = regexp.MustCompile(`\blink:([^\[\s]+)\[`)
+	adocImage       = regexp.MustCompile(`\bimage::?([^\[\s]+)\[`)
+	adocHeading     = regexp.MustCompile(`^(={1,6})\s+(\S.*?)\s*$`)
+	adocBlockAnchor = regexp.MustCompile(`^\[(?:\[([A-Za-z_:][\w:.-]*)(?:,[^\]]*)?\]|#([A-Za-z_:][\w:.-]*)[^\]]*)\]\s*$`)
+	adocAnchor      = regexp.MustCompile(`\[\[([A-Za-z_:][\w:.-]*)(?:,[^\]]*)?\]\]|\banchor:([A-Za-z_:][\w:.-]*)\[|\[#([A-Za-z_:][\w:.-]*)[^\]]*\]#`)
+)
+
+// AsciiDocExtractor reads AsciiDoc: include:: directives, xref: and <<>>
+// cross references, link: and image macros, section titles and anchors.
+// Listing, literal, passthrough and comment blocks are skipped, except that
+// include:: is honored inside listings as Asciidoctor does.
+type AsciiDocExtractor struct{}
+
+func (AsciiDocExtractor) Extract(content string) Document {
+	positions := newLineIndex(content)
+	links := make([]Link, 0)
+	doc := Document{}
+	delimiter := ""
+	pendingID := ""
+
+	for _, line := range splitLines(content) {
+		text := content[line.start:line.end]
+		trimmed := strings.TrimRight(text, " \t")
+		if isAdocDelimiter(trimmed) {
+			switch delimiter {
+			case "":
+				delimiter = trimmed
+			case trimmed:
+				delimiter = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(delimiter, "//") {
+			continue
+		}
+		if m := adocInclude.FindStringSubmatchIndex(text); m != nil {
+			links = append(links, Link{Kind: LinkInclude, Destination: text[m[2]:m[3]], Offset: line.start + m[2]})
+			continue
+		}
+		if delimiter != "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+
+		if m := adocBlockAnchor.FindStringSubmatch(trimmed); m != nil {
+			pendingID = m[1] + m[2]
+			doc.Anchors = append(doc.Anchors, pendingID)
+			continue
+		}
+		if m := adocHeading.FindStringSubmatch(trimmed); m != nil {
+			title := m[2]
+			id := pendingID
+			if id == "" {
+				id = adocID(title)
+			}
+			lineNo, _ := positions.position(line.start)
+			doc.Headings = append(doc.Headings, Heading{Text: title, ID: id, Level: len(m[1]), Line: lineNo})
+			pendingID = ""
+			continue
+		}
+		if trimmed != "" {
+			pendingID = ""
+		}
+
+		for _, m := range adocAnchor.FindAllStringSubmatch(text, -1) {
+			doc.Anchors = append(doc.Anchors, m[1]+m[2]+m[3])
+		}
+		links = append(links, adocInlineLinks(text, line.start)...)
+	}
+
+	doc.Links = resolveLinks(positions, links)
+	return doc
+}
+
+func adocInlineLinks(text string, offset int) []Link {
+	links := make([]Link, 0)
+	for _, m := range adocXref.FindAllStringSubmatchIndex(text, -1) {
+		links = append(links, Link{Kind: LinkXref, Destination: adocXrefTarget(text[m[2]:m[3]]), Offset: offset + m[2]})
+	}
+	for _, m := range adocCrossRef.FindAllStringSubmatchIndex(text, -1) {
+		links = append(links, Link{Kind: LinkXref, Destination: adocXrefTarget(text[m[2]:m[3]]), Offset: offset + m[2]})
+	}
+	for _, m := range adocLinkMacro.FindAllStringSubmatchIndex(text, -1) {
+		links = append(links, Link{Kind: LinkInline, Destination: text[m[2]:m[3]], Offset: offset + m[2]})
+	}
+	for _, m := range adocImage.FindAllStringSubmatchIndex(text, -1) {
+		links = append(links, Link{Kind: LinkImage, Destination: text[m[2]:m[3]], Offset: offset + m[2]})
+	}
+	return links
+}
+
+// adocXrefTarget turns an xref into a local destination: "file.adoc#id" and
+// "file#id" name another document, while a bare "id" refers to this one.
+func adocXrefTarget(raw string) string {
+	file, fragment, hasFragment := strings.Cut(raw, "#")
+	if !hasFragment && path.Ext(file) == "" {
+		return "#" + file
+	}
+	if file != "" && path.Ext(file) == "" {
+		file += ".adoc"
+	}
+	if !hasFragment || fragment == "" {
+		return file
+	}
+	return file + "#" + fragment
+}
+
+func isAdocDelimiter(line string) bool {
+	if len(line) < 4 {
+		return false
+	}
+	switch line[:4] {
+	case "----", "....", "++++", "////":
+		return strings.Count(line, line[:1]) == len(line)
+	}
+	return false
+}
+
+// adocID mirrors Asciidoctor's default section ids: an "_" prefix, then the
+// lowercased title with invalid characters dropped and spaces as "_".
+func adocID(title string) string {
+	var b strings.Builder
+	b.WriteByte('_')
+	pendingSep := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingSep {
+				b.WriteByte('_')
+			}
+			pendingSep = false
+			b.WriteRune(r)
+		case r == ' ' || r == '_' || r == '-' || r == '.':
+			pendingSep = b.Len() > 1
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,698 @@
+package parser
+
+import (
+	"strings"
+)
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+)
+
+type leafKind int
+
+const (
+	leafNone leafKind = iota
+	leafParagraph
+	leafFenced
+	leafIndented
+	leafHTML
+)
+
+type inlineBlock struct {
+	kind  blockKind
+	level int
+	start int
+	end   int
+}
+
+// fencedBlock is a fenced code block: its info string and the span of its
+// body lines, excluding both fence lines.
+type fencedBlock struct {
+	info  string
+	start int
+	end   int
+}
+
+type document struct {
+	text       string
+	blocks     []inlineBlock
+	htmlBlocks []inlineBlock
+	fences     []fencedBlock
+	refs       map[string]string
+}
+
+type container struct {
+	quote  bool
+	indent int
+}
+
+type leafState struct {
+	kind      leafKind
+	start     int
+	end       int
+	fenceChar byte
+	fenceLen  int
+	htmlType  int
+}
+
+type blockParser struct {
+	buf        []byte
+	containers []container
+	leaf       leafState
+	blocks     []inlineBlock
+	htmlBlocks []inlineBlock
+	fences     []fencedBlock
+	refs       map[string]string
+}
+
+type lineCursor struct {
+	buf []byte
+	pos int
+	end int
+	col int
+}
+
+var htmlBlockTags = map[string]struct{}{
+	"address": {}, "article": {}, "aside": {}, "base": {}, "basefont": {}, "blockquote": {}, "body": {},
+	"caption": {}, "center": {}, "col": {}, "colgroup": {}, "dd": {}, "details": {}, "dialog": {}, "dir": {},
+	"div": {}, "dl": {}, "dt": {}, "fieldset": {}, "figcaption": {}, "figure": {}, "footer": {}, "form": {},
+	"frame": {}, "frameset": {}, "h1": {}, "h2": {}, "h3": {}, "h4": {}, "h5": {}, "h6": {}, "head": {},
+	"header": {}, "hr": {}, "html": {}, "iframe": {}, "legend": {}, "li": {}, "link": {}, "main": {},
+	"menu": {}, "menuitem": {}, "nav": {}, "noframes": {}, "ol": {}, "optgroup": {}, "option": {}, "p": {},
+	"param": {}, "search": {}, "section": {}, "summary": {}, "table": {}, "tbody": {}, "td": {}, "tfoot": {},
+	"th": {}, "thead": {}, "title": {}, "tr": {}, "track": {}, "ul": {},
+}
+
+var htmlRawTags = []string{"pre", "script", "style", "textarea"}
+
+func parseDocument(content string) *document {
+	p := &blockParser{
+		buf:  []byte(content),
+		refs: make(map[string]string),
+	}
+
+	start := 0
+	for start <= len(p.buf) {
+		end := start
+		for end < len(p.buf) && p.buf[end] != '\n' {
+			end++
+		}
+		lineEnd := end
+		if lineEnd > start && p.buf[lineEnd-1] == '\r' {
+			lineEnd--
+		}
+		p.processLine(start, lineEnd)
+		if end >= len(p.buf) {
+			break
+		}
+		start = end + 1
+	}
+	p.closeContainers(0)
+
+	return &document{
+		text:       string(p.buf),
+		blocks:     p.blocks,
+		htmlBlocks: p.htmlBlocks,
+		fences:     p.fences,
+		refs:       p.refs,
+	}
+}
+
+func (p *blockParser) processLine(start, end int) {
+	c := &lineCursor{buf: p.buf, pos: start, end: end}
+
+	matched := p.matchContainers(c)
+	allMatched := matched == len(p.containers)
+
+	if allMatched && (p.leaf.kind == leafFenced || p.leaf.kind == leafHTML) {
+		p.continueRawLeaf(c)
+		return
+	}
+	if allMatched && p.leaf.kind == leafIndented {
+		if c.isBlank() || c.indent() >= 4 {
+			return
+		}
+		p.closeLeaf()
+	}
+
+	if !allMatched {
+		if p.leaf.kind == leafParagraph && !c.isBlank() && !p.interruptsParagraph(c) {
+			p.leaf.end = end
+			return
+		}
+		p.closeContainers(matched)
+	}
+
+	p.openContainers(c)
+	p.processLeaf(c, start, end)
+}
+
+func (p *blockParser) matchContainers(c *lineCursor) int {
+	matched := 0
+	for matched < len(p.containers) {
+		ct := p.containers[matched]
+		if ct.quote {
+			if c.isBlank() || c.indent() > 3 || c.peekNonSpace() != '>' {
+				break
+			}
+			c.consumeQuoteMarker()
+			matched++
+			continue
+		}
+		if c.isBlank() {
+			matched++
+			continue
+		}
+		if c.col+c.indent() < ct.indent {
+			break
+		}
+		c.skipColumns(ct.indent - c.col)
+		matched++
+	}
+	return matched
+}
+
+func (p *blockParser) openContainers(c *lineCursor) {
+	for !c.isBlank() && c.indent() < 4 {
+		if c.peekNonSpace() == '>' {
+			p.closeLeaf()
+			c.consumeQuoteMarker()
+			p.containers = append(p.containers, container{quote: true})
+			continue
+		}
+
+		rest := c.rest()
+		if isThematicBreak(rest) {
+			return
+		}
+		markerLen, ordered, startNum, ok := listMarker(rest)
+		if !ok {
+			return
+		}
+		afterMarker := rest[markerLen:]
+		empty := strings.TrimSpace(afterMarker) == ""
+		if p.leaf.kind == leafParagraph && (empty || (ordered && startNum != 1)) {
+			return
+		}
+
+		p.closeLeaf()
+		c.skipSpaces()
+		c.blank(markerLen)
+		c.advance(markerLen)
+		markerEndCol := c.col
+		spaces := c.indent()
+		switch {
+		case empty:
+			c.pos = c.end
+			p.containers = append(p.containers, container{indent: markerEndCol + 1})
+			return
+		case spaces > 4:
+			c.skipColumns(1)
+			p.containers = append(p.containers, container{indent: markerEndCol + 1})
+		default:
+			c.skipColumns(spaces)
+			p.containers = append(p.containers, container{indent: markerEndCol + spaces})
+		}
+	}
+}
+
+func (p *blockParser) processLeaf(c *lineCursor, start, end int) {
+	if c.isBlank() {
+		if p.leaf.kind == leafParagraph {
+			p.closeLeaf()
+		}
+		return
+	}
+
+	if c.indent() >= 4 {
+		if p.leaf.kind == leafParagraph {
+			p.leaf.end = end
+			return
+		}
+		p.closeLeaf()
+		p.leaf = leafState{kind: leafIndented}
+		return
+	}
+
+	c.skipSpaces()
+	rest := c.rest()
+
+	if ch, n, ok := openingFence(rest); ok {
+		p.closeLeaf()
+		p.leaf = leafState{kind: leafFenced, fenceChar: ch, fenceLen: n}
+		p.fences = append(p.fences, fencedBlock{info: strings.TrimSpace(rest[n:]), start: end, end: end})
+		return
+	}
+	if level, from, to, ok := atxHeading(rest); ok {
+		p.closeLeaf()
+		p.blocks = append(p.blocks, inlineBlock{kind: blockHeading, level: level, start: c.pos + from, end: c.pos + to})
+		return
+	}
+	if p.leaf.kind == leafParagraph {
+		if level, ok := setextUnderline(rest); ok {
+			p.closeParagraph(blockHeading, level)
+			return
+		}
+	}
+	if isThematicBreak(rest) {
+		p.closeLeaf()
+		return
+	}
+	if kind, ok := htmlBlockStart(rest, p.leaf.kind == leafParagraph); ok {
+		p.closeLeaf()
+		p.leaf = leafState{kind: leafHTML, htmlType: kind}
+		p.htmlBlocks = append(p.htmlBlocks, inlineBlock{start: c.pos, end: end})
+		if kind <= 5 && htmlBlockEnds(kind, rest) {
+			p.leaf = leafState{}
+		}
+		return
+	}
+
+	if p.leaf.kind == leafParagraph {
+		p.leaf.end = end
+		return
+	}
+	p.closeLeaf()
+	p.leaf = leafState{kind: leafParagraph, start: c.pos, end: end}
+}
+
+func (p *blockParser) continueRawLeaf(c *lineCursor) {
+	switch p.leaf.kind {
+	case leafFenced:
+		if c.indent() < 4 {
+			c.skipSpaces()
+			if closingFence(c.rest(), p.leaf.fenceChar, p.leaf.fenceLen) {
+				p.leaf = leafState{}
+				return
+			}
+		}
+		p.fences[len(p.fences)-1].end = c.end
+	case leafHTML:
+		if p.leaf.htmlType >= 6 && c.isBlank() {
+			p.leaf = leafState{}
+			return
+		}
+		p.htmlBlocks[len(p.htmlBlocks)-1].end = c.end
+		if p.leaf.htmlType >= 6 {
+			return
+		}
+		if htmlBlockEnds(p.leaf.htmlType, c.rest()) {
+			p.leaf = leafState{}
+		}
+	}
+}
+
+func (p *blockParser) interruptsParagraph(c *lineCursor) bool {
+	if c.indent() >= 4 {
+		return false
+	}
+	probe := *c
+	probe.skipSpaces()
+	rest := probe.rest()
+	if strings.HasPrefix(rest, ">") || isThematicBreak(rest) {
+		return true
+	}
+	if _, _, ok := openingFence(rest); ok {
+		return true
+	}
+	if _, _, _, ok := atxHeading(rest); ok {
+		return true
+	}
+	if _, ok := htmlBlockStart(rest, true); ok {
+		return true
+	}
+	if markerLen, ordered, startNum, ok := listMarker(rest); ok {
+		empty := strings.TrimSpace(rest[markerLen:]) == ""
+		return !empty && (!ordered || startNum == 1)
+	}
+	return false
+}
+
+func (p *blockParser) closeContainers(keep int) {
+	if keep < len(p.containers) || keep == 0 {
+		p.closeLeaf()
+	}
+	p.containers = p.containers[:keep]
+}
+
+func (p *blockParser) closeLeaf() {
+	if p.leaf.kind == leafParagraph {
+		p.closeParagraph(blockParagraph, 0)
+		return
+	}
+	p.leaf = leafState{}
+}
+
+func (p *blockParser) closeParagraph(kind blockKind, level int) {
+	start, end := p.leaf.start, p.leaf.end
+	p.leaf = leafState{}
+
+	text := string(p.buf[start:end])
+	pos := 0
+	for pos < len(text) {
+		label, dest, next, ok := parseReferenceDefinition(text, pos, len(text))
+		if !ok {
+			break
+		}
+		if _, exists := p.refs[label]; !exists {
+			p.refs[label] = dest
+		}
+		pos = next
+	}
+	if strings.TrimSpace(text[pos:]) == "" {
+		return
+	}
+	p.blocks = append(p.blocks, inlineBlock{kind: kind, level: level, start: start + pos, end: end})
+}
+
+func parseReferenceDefinition(s string, pos, end int) (label string, dest string, next int, ok bool) {
+	i := skipInlineSpaces(s, pos, end)
+	if i >= end || s[i] != '[' {
+		return "", "", pos, false
+	}
+	rawLabel, i, ok := scanLinkLabel(s, i, end)
+	if !ok || i >= end || s[i] != ':' {
+		return "", "", pos, false
+	}
+	label = normalizeLabel(rawLabel)
+	if label == "" {
+		return "", "", pos, false
+	}
+	i = skipWhitespaceOneNewline(s, i+1, end)
+	dest, afterDest, ok := scanDestination(s, i, end)
+	if !ok {
+		return "", "", pos, false
+	}
+
+	lineEnd, destLineBlank := restOfLineBlank(s, afterDest, end)
+	if titleStart := skipWhitespaceOneNewline(s, afterDest, end); titleStart > afterDest {
+		if titleEnd, ok := scanTitle(s, titleStart, end); ok {
+			if titleLineEnd, ok := restOfLineBlank(s, titleEnd, end); ok {
+				return label, dest, titleLineEnd, true
+			}
+		}
+	}
+	if !destLineBlank {
+		return "", "", pos, false
+	}
+	return label, dest, lineEnd, true
+}
+
+func restOfLineBlank(s string, i, end int) (int, bool) {
+	for i < end && (s[i] == ' ' || s[i] == '\t' || s[i] == '\r') {
+		i++
+	}
+	if i >= end {
+		return end, true
+	}
+	if s[i] == '\n' {
+		return i + 1, true
+	}
+	return i, false
+}
+
+func listMarker(s string) (markerLen int, ordered bool, start int, ok bool) {
+	if s == "" {
+		return 0, false, 0, false
+	}
+	switch s[0] {
+	case '-', '+', '*':
+		markerLen = 1
+	default:
+		digits := 0
+		for digits < len(s) && digits < 10 && s[digits] >= '0' && s[digits] <= '9' {
+			start = start*10 + int(s[digits]-'0')
+			digits++
+		}
+		if digits == 0 || digits > 9 || digits >= len(s) || (s[digits] != '.' && s[digits] != ')') {
+			return 0, false, 0, false
+		}
+		markerLen = digits + 1
+		ordered = true
+	}
+	if markerLen < len(s) && s[markerLen] != ' ' && s[markerLen] != '\t' {
+		return 0, false, 0, false
+	}
+	return markerLen, ordered, start, true
+}
+
+func isThematicBreak(s string) bool {
+	if s == "" {
+		return false
+	}
+	ch := s[0]
+	if ch != '*' && ch != '-' && ch != '_' {
+		return false
+	}
+	count := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case ch:
+			count++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return count >= 3
+}
+
+func openingFence(s string) (byte, int, bool) {
+	if s == "" || (s[0] != '`' && s[0] != '~') {
+		return 0, 0, false
+	}
+	ch := s[0]
+	n := 0
+	for n < len(s) && s[n] == ch {
+		n++
+	}
+	if n < 3 {
+		return 0, 0, false
+	}
+	if ch == '`' && strings.IndexByte(s[n:], '`') >= 0 {
+		return 0, 0, false
+	}
+	return ch, n, true
+}
+
+func closingFence(s string, ch byte, minLen int) bool {
+	n := 0
+	for n < len(s) && s[n] == ch {
+		n++
+	}
+	return n >= minLen && strings.TrimSpace(s[n:]) == ""
+}
+
+func atxHeading(s string) (level int, from int, to int, ok bool) {
+	for level < len(s) && s[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, 0, 0, false
+	}
+	if level < len(s) && s[level] != ' ' && s[level] != '\t' {
+		return 0, 0, 0, false
+	}
+
+	from = level
+	for from < len(s) && (s[from] == ' ' || s[from] == '\t') {
+		from++
+	}
+	to = len(s)
+	for to > from && (s[to-1] == ' ' || s[to-1] == '\t') {
+		to--
+	}
+	closing := to
+	for closing > from && s[closing-1] == '#' {
+		closing--
+	}
+	if closing < to && (closing == from || s[closing-1] == ' ' || s[closing-1] == '\t') {
+		to = closing
+		for to > from && (s[to-1] == ' ' || s[to-1] == '\t') {
+			to--
+		}
+	}
+	return level, from, to, true
+}
+
+func setextUnderline(s string) (int, bool) {
+	trimmed := strings.TrimRight(s, " \t")
+	if trimmed == "" {
+		return 0, false
+	}
+	ch := trimmed[0]
+	if ch != '=' && ch != '-' {
+		return 0, false
+	}
+	if strings.Trim(trimmed, string(ch)) != "" {
+		return 0, false
+	}
+	if ch == '=' {
+		return 1, true
+	}
+	return 2, true
+}
+
+func htmlBlockStart(s string, interruptingParagraph bool) (int, bool) {
+	if !strings.HasPrefix(s, "<") {
+		return 0, false
+	}
+	lower := strings.ToLower(s)
+	for _, tag := range htmlRawTags {
+		if strings.HasPrefix(lower, "<"+tag) {
+			rest := lower[len(tag)+1:]
+			if rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '>' {
+				return 1, true
+			}
+		}
+	}
+	switch {
+	case strings.HasPrefix(s, "<!--"):
+		return 2, true
+	case strings.HasPrefix(s, "<?"):
+		return 3, true
+	case strings.HasPrefix(s, "<![CDATA["):
+		return 5, true
+	case len(s) > 2 && s[1] == '!' && isASCIILetter(s[2]):
+		return 4, true
+	}
+
+	name := lower[1:]
+	name = strings.TrimPrefix(name, "/")
+	n := 0
+	for n < len(name) && (isASCIILetter(name[n]) || isASCIIDigit(name[n])) {
+		n++
+	}
+	if n > 0 {
+		if _, ok := htmlBlockTags[name[:n]]; ok {
+			rest := name[n:]
+			if rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '>' || strings.HasPrefix(rest, "/>") {
+				return 6, true
+			}
+		}
+	}
+
+	if interruptingParagraph {
+		return 0, false
+	}
+	if tag, next, ok := scanHTMLTag(s, 0, len(s), false); ok && strings.TrimSpace(s[next:]) == "" {
+		for _, raw := range htmlRawTags {
+			if tag.name == raw {
+				return 0, false
+			}
+		}
+		return 7, true
+	}
+	return 0, false
+}
+
+func htmlBlockEnds(kind int, s string) bool {
+	lower := strings.ToLower(s)
+	switch kind {
+	case 1:
+		for _, tag := range htmlRawTags {
+			if strings.Contains(lower, "</"+tag+">") {
+				return true
+			}
+		}
+		return false
+	case 2:
+		return strings.Contains(s, "-->")
+	case 3:
+		return strings.Contains(s, "?>")
+	case 4:
+		return strings.Contains(s, ">")
+	case 5:
+		return strings.Contains(s, "]]>")
+	}
+	return false
+}
+
+func (c *lineCursor) rest() string {
+	return string(c.buf[c.pos:c.end])
+}
+
+func (c *lineCursor) isBlank() bool {
+	for i := c.pos; i < c.end; i++ {
+		if c.buf[i] != ' ' && c.buf[i] != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *lineCursor) indent() int {
+	col := c.col
+	for i := c.pos; i < c.end; i++ {
+		switch c.buf[i] {
+		case ' ':
+			col++
+		case '\t':
+			col += 4 - col%4
+		default:
+			return col - c.col
+		}
+	}
+	return col - c.col
+}
+
+func (c *lineCursor) peekNonSpace() byte {
+	for i := c.pos; i < c.end; i++ {
+		if c.buf[i] != ' ' && c.buf[i] != '\t' {
+			return c.buf[i]
+		}
+	}
+	return 0
+}
+
+func (c *lineCursor) skipSpaces() {
+	c.skipColumns(c.indent())
+}
+
+func (c *lineCursor) skipColumns(n int) {
+	target := c.col + n
+	for c.pos < c.end && c.col < target {
+		switch c.buf[c.pos] {
+		case ' ':
+			c.col++
+		case '\t':
+			c.col += 4 - c.col%4
+		default:
+			return
+		}
+		c.pos++
+	}
+}
+
+func (c *lineCursor) consumeQuoteMarker() {
+	c.skipSpaces()
+	c.blank(1)
+	c.advance(1)
+	if c.pos < c.end && (c.buf[c.pos] == ' ' || c.buf[c.pos] == '\t') {
+		c.skipColumns(1)
+	}
+}
+
+func (c *lineCursor) advance(n int) {
+	for i := 0; i < n && c.pos < c.end; i++ {
+		c.pos++
+		c.col++
+	}
+}
+
+func (c *lineCursor) blank(n int) {
+	for i := c.pos; i < c.pos+n && i < c.end; i++ {
+		c.buf[i] = ' '
+	}
+}
+
+func isASCIILetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isASCIIDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
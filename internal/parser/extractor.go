@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Extractor reads the links, headings and anchors of one document format.
+type Extractor interface {
+	Extract(content string) Document
+}
+
+// Extractors maps lowercase file extensions (with the leading dot) to the
+// extractor for that format. Extensions without an entry use the "" entry,
+// or plain CommonMark when there is none.
+type Extractors map[string]Extractor
+
+type MarkdownExtractor struct {
+	Options Options
+}
+
+func (m MarkdownExtractor) Extract(content string) Document {
+	return ParseWith(content, m.Options)
+}
+
+// DefaultExtractors registers the built-in formats: markdown with opts, MDX
+// for .mdx, reStructuredText and AsciiDoc.
+func DefaultExtractors(opts Options) Extractors {
+	mdx := opts
+	mdx.MDX = true
+	opts.MDX = false
+	return Extractors{
+		"":          MarkdownExtractor{Options: opts},
+		".mdx":      MarkdownExtractor{Options: mdx},
+		".rst":      RSTExtractor{},
+		".rest":     RSTExtractor{},
+		".adoc":     AsciiDocExtractor{},
+		".asciidoc": AsciiDocExtractor{},
+		".asc":      AsciiDocExtractor{},
+	}
+}
+
+func (e Extractors) For(path string) Extractor {
+	if extractor, ok := e[strings.ToLower(filepath.Ext(path))]; ok {
+		return extractor
+	}
+	if extractor, ok := e[""]; ok {
+		return extractor
+	}
+	return MarkdownExtractor{}
+}
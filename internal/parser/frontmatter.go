@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+type FrontMatter struct {
+	Aliases       []string
+	Draft         bool
+	OrphanAllowed bool
+	Permalink     string
+}
+
+type frontMatterValue struct {
+	scalar string
+	list   []string
+	fields map[string]string
+}
+
+// splitFrontMatter reports the YAML (---) or TOML (+++) block at the start of
+// content, returning its body and the offset where the markdown begins.
+func splitFrontMatter(content string) (delim string, body string, end int, ok bool) {
+	bom := 0
+	if strings.HasPrefix(content, "\uFEFF") {
+		bom = len("\uFEFF")
+	}
+	first, rest, found := strings.Cut(content[bom:], "\n")
+	if !found {
+		return "", "", 0, false
+	}
+	delim = strings.TrimRight(first, " \t\r")
+	if delim != "---" && delim != "+++" {
+		return "", "", 0, false
+	}
+
+	bodyStart := bom + len(first) + 1
+	offset := bodyStart
+	for {
+		line, next, more := strings.Cut(rest, "\n")
+		trimmed := strings.TrimRight(line, " \t\r")
+		if trimmed == delim || (delim == "---" && trimmed == "...") {
+			end = offset + len(line)
+			if more {
+				end++
+			}
+			return delim, content[bodyStart:offset], end, true
+		}
+		if !more {
+			return "", "", 0, false
+		}
+		offset += len(line) + 1
+		rest = next
+	}
+}
+
+func parseFrontMatter(delim, body string) FrontMatter {
+	var values map[string]frontMatterValue
+	if delim == "+++" {
+		values = parseTOMLFrontMatter(body)
+	} else {
+		values = parseYAMLFrontMatter(body)
+	}
+
+	fm := FrontMatter{}
+	for _, key := range []string{"aliases", "alias"} {
+		v, ok := values[key]
+		if !ok {
+			continue
+		}
+		if v.list != nil {
+			fm.Aliases = append(fm.Aliases, v.list...)
+		} else if v.scalar != "" {
+			fm.Aliases = append(fm.Aliases, v.scalar)
+		}
+	}
+	if v, ok := values["permalink"]; ok {
+		fm.Permalink = v.scalar
+	}
+	if v, ok := values["draft"]; ok {
+		fm.Draft = isTruthy(v.scalar)
+	}
+	if v, ok := values["gorphan"]; ok {
+		orphan := strings.ToLower(strings.TrimSpace(v.fields["orphan"]))
+		fm.OrphanAllowed = orphan == "allowed" || orphan == "allow" || isTruthy(orphan)
+	}
+	return fm
+}
+
+// isTruthy accepts the YAML 1.1 true values (true, yes, y, on) in any case,
+// plus the 1 and t forms strconv.ParseBool reads.
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "y", "on", "1", "t":
+		return true
+	}
+	return false
+}
+
+func parseYAMLFrontMatter(body string) map[string]frontMatterValue {
+	values := make(map[string]frontMatterValue)
+	current := ""
+	for _, raw := range strings.Split(body, "\n") {
+		line := stripFrontMatterComment(strings.TrimRight(raw, " \t\r"))
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		trimmed := strings.TrimSpace(line)
+
+		if indented || strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if current == "" {
+				continue
+			}
+			v := values[current]
+			if item, ok := strings.CutPrefix(trimmed, "-"); ok {
+				v.list = append(v.list, unquoteFrontMatter(item))
+			} else if key, value, ok := strings.Cut(trimmed, ":"); ok {
+				if v.fields == nil {
+					v.fields = make(map[string]string)
+				}
+				v.fields[strings.TrimSpace(key)] = unquoteFrontMatter(value)
+			}
+			values[current] = v
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			current = ""
+			continue
+		}
+		current = strings.TrimSpace(key)
+		values[current] = parseFlowValue(strings.TrimSpace(value), ":")
+	}
+	return values
+}
+
+func parseTOMLFrontMatter(body string) map[string]frontMatterValue {
+	values := make(map[string]frontMatterValue)
+	table := ""
+	for _, raw := range strings.Split(body, "\n") {
+		line := strings.TrimSpace(stripFrontMatterComment(raw))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") && !strings.HasPrefix(line, "[[") {
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = unquoteFrontMatter(key)
+		if table != "" {
+			v := values[table]
+			if v.fields == nil {
+				v.fields = make(map[string]string)
+			}
+			v.fields[key] = unquoteFrontMatter(value)
+			values[table] = v
+			continue
+		}
+		values[key] = parseFlowValue(strings.TrimSpace(value), "=")
+	}
+	return values
+}
+
+func parseFlowValue(value, sep string) frontMatterValue {
+	switch {
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		list := make([]string, 0)
+		for _, item := range splitFlowItems(value[1 : len(value)-1]) {
+			list = append(list, unquoteFrontMatter(item))
+		}
+		return frontMatterValue{list: list}
+	case strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}"):
+		fields := make(map[string]string)
+		for _, item := range splitFlowItems(value[1 : len(value)-1]) {
+			if key, v, ok := strings.Cut(item, sep); ok {
+				fields[unquoteFrontMatter(key)] = unquoteFrontMatter(v)
+			}
+		}
+		return frontMatterValue{fields: fields}
+	}
+	return frontMatterValue{scalar: unquoteFrontMatter(value)}
+}
+
+func splitFlowItems(s string) []string {
+	items := make([]string, 0)
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		items = append(items, s[start:])
+	}
+	return items
+}
+
+func stripFrontMatterComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquoteFrontMatter(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 {
+		switch {
+		case value[0] == '"' && value[len(value)-1] == '"':
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted
+			}
+			return value[1 : len(value)-1]
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+	}
+	return value
+}
@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"html"
+	"strings"
+)
+
+func headingText(raw string) (string, string) {
+	raw, id := splitHeadingAttributes(strings.TrimSpace(raw))
+	return collapseLines(plainInlineText(raw)), id
+}
+
+func splitHeadingAttributes(s string) (string, string) {
+	if !strings.HasSuffix(s, "}") {
+		return s, ""
+	}
+	open := strings.LastIndexByte(s, '{')
+	if open < 0 || (open > 0 && s[open-1] == '\\') {
+		return s, ""
+	}
+	id := ""
+	for _, field := range strings.Fields(strings.TrimPrefix(s[open+1:len(s)-1], ":")) {
+		switch {
+		case strings.HasPrefix(field, "#") && len(field) > 1:
+			id = field[1:]
+		case strings.HasPrefix(field, ".") && len(field) > 1:
+		case strings.Contains(field, "=") && !strings.HasPrefix(field, "="):
+		default:
+			return s, ""
+		}
+	}
+	if id == "" {
+		return s, ""
+	}
+	return strings.TrimSpace(s[:open]), id
+}
+
+func plainInlineText(s string) string {
+	var out strings.Builder
+	var text strings.Builder
+	flush := func() {
+		out.WriteString(html.UnescapeString(text.String()))
+		text.Reset()
+	}
+
+	end := len(s)
+	for i := 0; i < end; {
+		switch ch := s[i]; ch {
+		case '\\':
+			if i+1 < end && isASCIIPunct(s[i+1]) {
+				text.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+			text.WriteByte(ch)
+			i++
+		case '`':
+			run := i
+			for run < end && s[run] == '`' {
+				run++
+			}
+			next := skipCodeSpan(s, i, end)
+			if next == run {
+				text.WriteString(s[i:run])
+				i = run
+				continue
+			}
+			code := s[run : next-(run-i)]
+			if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			flush()
+			out.WriteString(code)
+			i = next
+		case '<':
+			if next, ok := scanAutolink(s, i, end); ok {
+				text.WriteString(s[i+1 : next-1])
+				i = next
+				continue
+			}
+			if next, ok := scanInlineHTML(s, i, end); ok {
+				i = next
+				continue
+			}
+			text.WriteByte(ch)
+			i++
+		case '!':
+			if i+1 < end && s[i+1] == '[' {
+				i++
+				continue
+			}
+			text.WriteByte(ch)
+			i++
+		case '[':
+			i++
+		case ']':
+			i++
+			if i < end && s[i] == '(' {
+				if _, next, ok := scanInlineLinkTail(s, i+1, end); ok {
+					i = next
+				}
+			} else if i < end && s[i] == '[' {
+				if _, next, ok := scanLinkLabel(s, i, end); ok {
+					i = next
+				}
+			}
+		case '*', '_', '~':
+			run := i
+			for run < end && s[run] == ch {
+				run++
+			}
+			if ch == '_' && i > 0 && run < end && isWordByte(s[i-1]) && isWordByte(s[run]) {
+				text.WriteString(s[i:run])
+			}
+			i = run
+		default:
+			text.WriteByte(ch)
+			i++
+		}
+	}
+	flush()
+	return out.String()
+}
+
+func collapseLines(s string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return strings.TrimSpace(s)
+	}
+	lines := strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == '\r' })
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, " "))
+}
+
+func isWordByte(ch byte) bool {
+	return isASCIILetter(ch) || isASCIIDigit(ch) || ch >= 0x80
+}
+
+func blockIDs(text string) []string {
+	ids := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		i := strings.LastIndexByte(line, '^')
+		if i < 0 || i == len(line)-1 || (i > 0 && line[i-1] != ' ' && line[i-1] != '\t') {
+			continue
+		}
+		id := line[i+1:]
+		if strings.IndexFunc(id, func(r rune) bool {
+			return !(r == '-' || (r < 0x80 && (isASCIILetter(byte(r)) || isASCIIDigit(byte(r)))))
+		}) >= 0 {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
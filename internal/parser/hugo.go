@@ -0,0 +1,30 @@
+package parser
+
+import "regexp"
+
+var hugoRefShortcode = regexp.MustCompile(`\{\{[<%]\s*(relref|ref)\s+(?:path\s*=\s*)?(?:"([^"]*)"|` + "`([^`]*)`" + `)[^}]*?[>%]\}\}`)
+
+// hugoShortcodes returns ref and relref shortcodes found outside code blocks.
+// Their destinations follow Hugo's lookup rules, so graph resolves them
+// rather than treating them as plain relative paths.
+func hugoShortcodes(doc *document) []Link {
+	links := make([]Link, 0)
+	for _, blocks := range [][]inlineBlock{doc.blocks, doc.htmlBlocks} {
+		for _, block := range blocks {
+			text := doc.text[block.start:block.end]
+			for _, m := range hugoRefShortcode.FindAllStringSubmatchIndex(text, -1) {
+				start, end := m[4], m[5]
+				if start < 0 {
+					start, end = m[6], m[7]
+				}
+				links = append(links, Link{
+					Kind:        LinkShortcode,
+					Text:        text[m[2]:m[3]],
+					Destination: text[start:end],
+					Offset:      block.start + start,
+				})
+			}
+		}
+	}
+	return links
+}
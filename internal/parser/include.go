@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IncludePattern recognizes one include directive syntax. The first
+// non-empty capture group of each match is the included path.
+type IncludePattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Raw matches the whole page, code blocks included, the way a
+	// preprocessor sees it. Other patterns skip code blocks.
+	Raw bool
+	// Block, when set, matches a multi-line form whose first capture group
+	// holds one path per line.
+	Block *regexp.Regexp
+}
+
+var builtinIncludes = map[string]IncludePattern{
+	// pymdownx.snippets: --8<-- "file.md", optionally with :start:end lines,
+	// or a block of paths between two bare --8<-- lines.
+	"snippets": {
+		Pattern: regexp.MustCompile(`(?m)^[ \t]*-+8<-+[ \t]+(?:"([^"\n]+?)(?::-?\d*)*"|'([^'\n]+?)(?::-?\d*)*')`),
+		Raw:     true,
+		Block:   regexp.MustCompile(`(?m)^[ \t]*-+8<-+[ \t]*\r?\n((?:.*\n)*?)[ \t]*-+8<-+[ \t]*\r?$`),
+	},
+	// markdown-include: {!file.md!}
+	"markdown-include": {
+		Pattern: regexp.MustCompile(`\{!\s*([^!\n]+?)\s*!\}`),
+		Raw:     true,
+	},
+	// <!-- include: file.md -->
+	"comment": {
+		Pattern: regexp.MustCompile(`<!--\s*include:?\s+(\S+?)\s*-->`),
+	},
+}
+
+// snippetRange is the optional :start:end line selection after a snippet
+// path.
+var snippetRange = regexp.MustCompile(`(?::-?\d*)+$`)
+
+// ParseIncludePatterns turns entries into include directive patterns. An
+// entry is a built-in name (snippets, markdown-include, comment) or a regular
+// expression with at least one capture group.
+func ParseIncludePatterns(entries []string) ([]IncludePattern, error) {
+	out := make([]IncludePattern, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if pattern, ok := builtinIncludes[entry]; ok {
+			pattern.Name = entry
+			out = append(out, pattern)
+			continue
+		}
+		pattern, err := regexp.Compile(entry)
+		if err != nil || pattern.NumSubexp() == 0 {
+			return nil, fmt.Errorf("invalid include pattern %q: want snippets, markdown-include, comment or a regexp with a capture group", entry)
+		}
+		out = append(out, IncludePattern{Name: "pattern", Pattern: pattern})
+	}
+	return out, nil
+}
+
+// includeDirectives returns the paths named by include directives. Raw
+// patterns search the whole page; the others search only text outside code
+// blocks, plus HTML blocks where comment directives live.
+func includeDirectives(doc *document, patterns []IncludePattern) []Link {
+	links := make([]Link, 0)
+	whole := []inlineBlock{{start: 0, end: len(doc.text)}}
+	for _, include := range patterns {
+		searched := [][]inlineBlock{doc.blocks, doc.htmlBlocks}
+		if include.Raw {
+			searched = [][]inlineBlock{whole}
+		}
+		for _, blocks := range searched {
+			for _, block := range blocks {
+				text := doc.text[block.start:block.end]
+				links = append(links, includeMatches(include, text, block.start)...)
+				if include.Block != nil {
+					links = append(links, includeBlocks(include, text, block.start)...)
+				}
+			}
+		}
+	}
+	return links
+}
+
+func includeMatches(include IncludePattern, text string, offset int) []Link {
+	links := make([]Link, 0)
+	for _, m := range include.Pattern.FindAllStringSubmatchIndex(text, -1) {
+		for g := 2; g+1 < len(m); g += 2 {
+			if m[g] < 0 || m[g] == m[g+1] {
+				continue
+			}
+			links = append(links, Link{Kind: LinkInclude, Text: include.Name, Destination: text[m[g]:m[g+1]], Offset: offset + m[g]})
+			break
+		}
+	}
+	return links
+}
+
+// includeBlocks reads the block form: one path per line, skipping blank lines
+// and lines commented out with ";".
+func includeBlocks(include IncludePattern, text string, offset int) []Link {
+	links := make([]Link, 0)
+	for _, m := range include.Block.FindAllStringSubmatchIndex(text, -1) {
+		if len(m) < 4 || m[2] < 0 {
+			continue
+		}
+		for _, line := range splitLines(text[m[2]:m[3]]) {
+			raw := text[m[2]+line.start : m[2]+line.end]
+			path := strings.TrimSpace(raw)
+			if path == "" || strings.HasPrefix(path, ";") {
+				continue
+			}
+			path = snippetRange.ReplaceAllString(path, "")
+			lead := len(raw) - len(strings.TrimLeft(raw, " \t"))
+			links = append(links, Link{Kind: LinkInclude, Text: include.Name, Destination: path, Offset: offset + m[2] + line.start + lead})
+		}
+	}
+	return links
+}
@@ -0,0 +1,664 @@
+package parser
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+type bracketOpener struct {
+	pos    int
+	image  bool
+	active bool
+}
+
+type inlineParser struct {
+	src       string
+	end       int
+	refs      map[string]string
+	jsx       bool
+	linkAttrs map[string][]string
+	openers   []bracketOpener
+	links     []Link
+	anchors   []string
+}
+
+type htmlAttr struct {
+	name   string
+	value  string
+	offset int
+}
+
+type htmlTag struct {
+	name    string
+	closing bool
+	attrs   []htmlAttr
+}
+
+var htmlLinkAttrs = map[string][]string{
+	"img": {"src"},
+}
+
+func extractLinks(doc *document, opts Options) ([]Link, []string) {
+	linkAttrs := htmlLinkAttrs
+	if opts.MDX {
+		linkAttrs = make(map[string][]string, len(htmlLinkAttrs)+len(opts.JSXLinks))
+		for name, attrs := range htmlLinkAttrs {
+			linkAttrs[name] = append(linkAttrs[name], attrs...)
+		}
+		for name, attrs := range opts.JSXLinks {
+			linkAttrs[name] = append(linkAttrs[name], attrs...)
+		}
+	}
+	links := make([]Link, 0)
+	anchors := make([]string, 0)
+	for _, block := range doc.blocks {
+		p := inlineParser{src: doc.text, end: block.end, refs: doc.refs, jsx: opts.MDX, linkAttrs: linkAttrs}
+		p.parse(block.start)
+		links = append(links, p.links...)
+		anchors = append(anchors, p.anchors...)
+	}
+	for _, block := range doc.htmlBlocks {
+		// MDX treats text between JSX tags as markdown, so its "HTML blocks"
+		// get the full inline pass instead of the tag-only scan.
+		p := inlineParser{src: doc.text, end: block.end, refs: doc.refs, jsx: opts.MDX, linkAttrs: linkAttrs}
+		if opts.MDX {
+			p.parse(block.start)
+		} else {
+			p.parseHTML(block.start)
+		}
+		links = append(links, p.links...)
+		anchors = append(anchors, p.anchors...)
+	}
+	sort.SliceStable(links, func(i, j int) bool { return links[i].Offset < links[j].Offset })
+	return links, anchors
+}
+
+func (p *inlineParser) parse(start int) {
+	s := p.src
+	i := start
+	for i < p.end {
+		switch s[i] {
+		case '\\':
+			if i+1 < p.end && isASCIIPunct(s[i+1]) {
+				i += 2
+				continue
+			}
+			i++
+		case '`':
+			i = skipCodeSpan(s, i, p.end)
+		case '<':
+			if next, ok := scanAutolink(s, i, p.end); ok {
+				i = next
+				continue
+			}
+			if tag, next, ok := scanHTMLTag(s, i, p.end, p.jsx); ok {
+				p.htmlTag(tag)
+				i = next
+				continue
+			}
+			if next, ok := scanInlineHTML(s, i, p.end); ok {
+				i = next
+				continue
+			}
+			i++
+		case '!':
+			if i+1 < p.end && s[i+1] == '[' {
+				if next, ok := p.wikiLink(i, i+1); ok {
+					i = next
+					continue
+				}
+				p.openers = append(p.openers, bracketOpener{pos: i + 1, image: true, active: true})
+				i += 2
+				continue
+			}
+			i++
+		case '[':
+			if next, ok := p.wikiLink(i, i); ok {
+				i = next
+				continue
+			}
+			p.openers = append(p.openers, bracketOpener{pos: i, active: true})
+			i++
+		case ']':
+			i = p.closeBracket(i)
+		default:
+			i++
+		}
+	}
+}
+
+func (p *inlineParser) parseHTML(start int) {
+	s := p.src
+	for i := start; i < p.end; {
+		if s[i] != '<' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:p.end], "<!--") {
+			next, ok := scanUntil(s, i+4, p.end, "-->")
+			if !ok {
+				return
+			}
+			i = next
+			continue
+		}
+		if tag, next, ok := scanHTMLTag(s, i, p.end, p.jsx); ok {
+			p.htmlTag(tag)
+			i = next
+			continue
+		}
+		i++
+	}
+}
+
+func (p *inlineParser) htmlTag(tag htmlTag) {
+	if tag.closing {
+		return
+	}
+	for _, attr := range tag.attrs {
+		if attr.name == "id" || (attr.name == "name" && tag.name == "a") {
+			p.anchors = append(p.anchors, attr.value)
+		}
+	}
+	attrNames, ok := p.linkAttrs[tag.name]
+	if !ok {
+		return
+	}
+	text := ""
+	for _, attr := range tag.attrs {
+		if attr.name == "alt" {
+			text = attr.value
+		}
+	}
+	for _, attr := range tag.attrs {
+		if slices.Contains(attrNames, attr.name) && attr.value != "" {
+			p.links = append(p.links, Link{Kind: LinkHTML, Text: text, Destination: attr.value, Offset: attr.offset})
+		}
+	}
+}
+
+func (p *inlineParser) wikiLink(start, i int) (int, bool) {
+	s := p.src
+	if i+1 >= p.end || s[i] != '[' || s[i+1] != '[' {
+		return i, false
+	}
+	for j := i + 2; j+1 < p.end; j++ {
+		switch s[j] {
+		case '\n', '[':
+			return i, false
+		case ']':
+			if s[j+1] != ']' || j == i+2 {
+				return i, false
+			}
+			dest, text := s[i+2:j], s[i+2:j]
+			if bar := strings.IndexByte(dest, '|'); bar >= 0 {
+				dest, text = dest[:bar], dest[bar+1:]
+			}
+			kind := LinkWiki
+			if start != i {
+				kind = LinkEmbed
+			}
+			p.links = append(p.links, Link{Kind: kind, Text: strings.TrimSpace(text), Destination: strings.TrimSpace(dest), Offset: start})
+			return j + 2, true
+		}
+	}
+	return i, false
+}
+
+func (p *inlineParser) closeBracket(i int) int {
+	if len(p.openers) == 0 {
+		return i + 1
+	}
+	opener := p.openers[len(p.openers)-1]
+	p.openers = p.openers[:len(p.openers)-1]
+	if !opener.active {
+		return i + 1
+	}
+
+	s := p.src
+	text := s[opener.pos+1 : i]
+	start := opener.pos
+	kind := LinkInline
+	if opener.image {
+		start--
+		kind = LinkImage
+	}
+
+	if i+1 < p.end && s[i+1] == '(' {
+		if dest, next, ok := scanInlineLinkTail(s, i+2, p.end); ok {
+			p.emit(Link{Kind: kind, Text: text, Destination: dest, Offset: start})
+			return next
+		}
+	}
+
+	label := text
+	next := i + 1
+	if rawLabel, after, ok := scanLinkLabel(s, i+1, p.end); ok {
+		if strings.TrimSpace(rawLabel) != "" {
+			label = rawLabel
+		}
+		next = after
+	}
+	dest, ok := p.refs[normalizeLabel(label)]
+	if !ok {
+		return i + 1
+	}
+	if !opener.image {
+		kind = LinkReference
+	}
+	p.emit(Link{Kind: kind, Text: text, Destination: dest, Offset: start})
+	return next
+}
+
+func (p *inlineParser) emit(link Link) {
+	p.links = append(p.links, link)
+	if link.Kind == LinkImage {
+		return
+	}
+	for i := range p.openers {
+		if !p.openers[i].image {
+			p.openers[i].active = false
+		}
+	}
+}
+
+func skipCodeSpan(s string, i, end int) int {
+	n := 0
+	for i+n < end && s[i+n] == '`' {
+		n++
+	}
+	j := i + n
+	for j < end {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		run := 0
+		for j+run < end && s[j+run] == '`' {
+			run++
+		}
+		if run == n {
+			return j + run
+		}
+		j += run
+	}
+	return i + n
+}
+
+func scanInlineLinkTail(s string, i, end int) (string, int, bool) {
+	i = skipWhitespaceOneNewline(s, i, end)
+	if i < end && s[i] == ')' {
+		return "", i + 1, true
+	}
+	dest, afterDest, ok := scanDestination(s, i, end)
+	if !ok {
+		return "", i, false
+	}
+	j := skipWhitespaceOneNewline(s, afterDest, end)
+	if j < end && s[j] == ')' {
+		return dest, j + 1, true
+	}
+	if j == afterDest {
+		return "", i, false
+	}
+	titleEnd, ok := scanTitle(s, j, end)
+	if !ok {
+		return "", i, false
+	}
+	j = skipWhitespaceOneNewline(s, titleEnd, end)
+	if j < end && s[j] == ')' {
+		return dest, j + 1, true
+	}
+	return "", i, false
+}
+
+func scanDestination(s string, i, end int) (string, int, bool) {
+	if i < end && s[i] == '<' {
+		for j := i + 1; j < end; j++ {
+			switch s[j] {
+			case '\\':
+				j++
+			case '\n', '<':
+				return "", i, false
+			case '>':
+				return s[i+1 : j], j + 1, true
+			}
+		}
+		return "", i, false
+	}
+
+	depth := 0
+	j := i
+loop:
+	for j < end {
+		ch := s[j]
+		switch {
+		case ch == '\\' && j+1 < end && (isASCIIPunct(s[j+1]) || s[j+1] == ' '):
+			j += 2
+			continue
+		case ch == '(':
+			depth++
+			if depth > 32 {
+				return "", i, false
+			}
+		case ch == ')':
+			if depth == 0 {
+				break loop
+			}
+			depth--
+		case ch <= ' ' || ch == 0x7f:
+			break loop
+		}
+		j++
+	}
+	if j == i || depth != 0 {
+		return "", i, false
+	}
+	return s[i:j], j, true
+}
+
+func scanTitle(s string, i, end int) (int, bool) {
+	if i >= end {
+		return i, false
+	}
+	closer := s[i]
+	switch closer {
+	case '"', '\'':
+	case '(':
+		closer = ')'
+	default:
+		return i, false
+	}
+	for j := i + 1; j < end; j++ {
+		switch {
+		case s[j] == '\\':
+			j++
+		case s[j] == closer:
+			return j + 1, true
+		case closer == ')' && s[j] == '(':
+			return i, false
+		}
+	}
+	return i, false
+}
+
+func scanLinkLabel(s string, i, end int) (string, int, bool) {
+	if i >= end || s[i] != '[' {
+		return "", i, false
+	}
+	for j := i + 1; j < end && j-i <= 1000; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			return "", i, false
+		case ']':
+			return s[i+1 : j], j + 1, true
+		}
+	}
+	return "", i, false
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+func skipInlineSpaces(s string, i, end int) int {
+	for i < end && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func skipWhitespaceOneNewline(s string, i, end int) int {
+	i = skipInlineSpaces(s, i, end)
+	if i < end && s[i] == '\r' {
+		i++
+	}
+	if i < end && s[i] == '\n' {
+		i = skipInlineSpaces(s, i+1, end)
+	}
+	return i
+}
+
+func scanAutolink(s string, i, end int) (int, bool) {
+	if i >= end || s[i] != '<' {
+		return i, false
+	}
+	j := i + 1
+	for j < end && (isASCIILetter(s[j]) || isASCIIDigit(s[j]) || s[j] == '+' || s[j] == '.' || s[j] == '-') {
+		j++
+	}
+	scheme := j - i - 1
+	if scheme >= 2 && scheme <= 32 && isASCIILetter(s[i+1]) && j < end && s[j] == ':' {
+		for k := j + 1; k < end; k++ {
+			switch {
+			case s[k] == '>':
+				return k + 1, true
+			case s[k] <= ' ' || s[k] == '<':
+				return i, false
+			}
+		}
+		return i, false
+	}
+
+	at := -1
+	for k := i + 1; k < end; k++ {
+		switch {
+		case s[k] == '@' && at < 0 && k > i+1:
+			at = k
+		case s[k] == '>' && at > 0 && k > at+1:
+			return k + 1, true
+		case s[k] <= ' ' || s[k] == '<' || s[k] == '>' || s[k] == '\\':
+			return i, false
+		}
+	}
+	return i, false
+}
+
+func scanInlineHTML(s string, i, end int) (int, bool) {
+	rest := s[i:end]
+	switch {
+	case strings.HasPrefix(rest, "<!-->"):
+		return i + 5, true
+	case strings.HasPrefix(rest, "<!--->"):
+		return i + 6, true
+	case strings.HasPrefix(rest, "<!--"):
+		return scanUntil(s, i+4, end, "-->")
+	case strings.HasPrefix(rest, "<?"):
+		return scanUntil(s, i+2, end, "?>")
+	case strings.HasPrefix(rest, "<![CDATA["):
+		return scanUntil(s, i+9, end, "]]>")
+	case len(rest) > 2 && rest[1] == '!' && isASCIILetter(rest[2]):
+		return scanUntil(s, i+2, end, ">")
+	}
+	_, next, ok := scanHTMLTag(s, i, end, false)
+	return next, ok
+}
+
+func scanUntil(s string, i, end int, marker string) (int, bool) {
+	if idx := strings.Index(s[i:end], marker); idx >= 0 {
+		return i + idx + len(marker), true
+	}
+	return i, false
+}
+
+// scanHTMLTag reads one open or closing tag. In jsx mode component names keep
+// their case and may contain dots, and attributes may use {expression} values
+// or {...spread} props.
+func scanHTMLTag(s string, i, end int, jsx bool) (htmlTag, int, bool) {
+	if i >= end || s[i] != '<' {
+		return htmlTag{}, i, false
+	}
+	j := i + 1
+	tag := htmlTag{}
+	if j < end && s[j] == '/' {
+		tag.closing = true
+		j++
+	}
+	nameStart := j
+	if j >= end || !isASCIILetter(s[j]) {
+		return htmlTag{}, i, false
+	}
+	for j < end && (isASCIILetter(s[j]) || isASCIIDigit(s[j]) || s[j] == '-' || (jsx && s[j] == '.')) {
+		j++
+	}
+	tag.name = s[nameStart:j]
+	if !jsx {
+		tag.name = strings.ToLower(tag.name)
+	}
+
+	if tag.closing {
+		j = skipHTMLSpace(s, j, end)
+		if j < end && s[j] == '>' {
+			return tag, j + 1, true
+		}
+		return htmlTag{}, i, false
+	}
+
+	for {
+		k := skipHTMLSpace(s, j, end)
+		if k >= end {
+			return htmlTag{}, i, false
+		}
+		if s[k] == '>' {
+			return tag, k + 1, true
+		}
+		if s[k] == '/' && k+1 < end && s[k+1] == '>' {
+			return tag, k + 2, true
+		}
+		if jsx && k > j && s[k] == '{' {
+			next, ok := skipJSXExpression(s, k, end)
+			if !ok {
+				return htmlTag{}, i, false
+			}
+			j = next
+			continue
+		}
+		if k == j || !isAttrNameStart(s[k]) {
+			return htmlTag{}, i, false
+		}
+		attr, next, ok := scanHTMLAttr(s, k, end, jsx)
+		if !ok {
+			return htmlTag{}, i, false
+		}
+		tag.attrs = append(tag.attrs, attr)
+		j = next
+	}
+}
+
+func scanHTMLAttr(s string, i, end int, jsx bool) (htmlAttr, int, bool) {
+	j := i
+	for j < end && isAttrNameChar(s[j]) {
+		j++
+	}
+	attr := htmlAttr{name: strings.ToLower(s[i:j])}
+	k := skipHTMLSpace(s, j, end)
+	if k >= end || s[k] != '=' {
+		return attr, j, true
+	}
+	k = skipHTMLSpace(s, k+1, end)
+	if k >= end {
+		return htmlAttr{}, i, false
+	}
+	if jsx && s[k] == '{' {
+		next, ok := skipJSXExpression(s, k, end)
+		if !ok {
+			return htmlAttr{}, i, false
+		}
+		attr.value, attr.offset = jsxStringLiteral(s, k+1, next-1)
+		return attr, next, true
+	}
+	switch quote := s[k]; quote {
+	case '"', '\'':
+		closeIdx := strings.IndexByte(s[k+1:end], quote)
+		if closeIdx < 0 {
+			return htmlAttr{}, i, false
+		}
+		attr.value = s[k+1 : k+1+closeIdx]
+		attr.offset = k + 1
+		return attr, k + closeIdx + 2, true
+	default:
+		v := k
+		for v < end && !strings.ContainsRune(" \t\r\n\"'=<>`", rune(s[v])) {
+			v++
+		}
+		if v == k {
+			return htmlAttr{}, i, false
+		}
+		attr.value = s[k:v]
+		attr.offset = k
+		return attr, v, true
+	}
+}
+
+// skipJSXExpression returns the offset after the "}" matching the "{" at i,
+// skipping braces inside string and template literals.
+func skipJSXExpression(s string, i, end int) (int, bool) {
+	depth := 0
+	var quote byte
+	for j := i; j < end; j++ {
+		switch ch := s[j]; {
+		case quote != 0:
+			if ch == '\\' {
+				j++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return j + 1, true
+			}
+		}
+	}
+	return i, false
+}
+
+// jsxStringLiteral returns the contents of an expression that is a single
+// string or substitution-free template literal; other expressions are not
+// static link targets and yield "".
+func jsxStringLiteral(s string, from, to int) (string, int) {
+	for from < to && strings.IndexByte(" \t\r\n", s[from]) >= 0 {
+		from++
+	}
+	for to > from && strings.IndexByte(" \t\r\n", s[to-1]) >= 0 {
+		to--
+	}
+	if to-from < 2 {
+		return "", 0
+	}
+	quote := s[from]
+	if (quote != '"' && quote != '\'' && quote != '`') || s[to-1] != quote {
+		return "", 0
+	}
+	value := s[from+1 : to-1]
+	if strings.IndexByte(value, quote) >= 0 || (quote == '`' && strings.Contains(value, "${")) {
+		return "", 0
+	}
+	return value, from + 1
+}
+
+func skipHTMLSpace(s string, i, end int) int {
+	for i < end && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+func isAttrNameStart(ch byte) bool {
+	return isASCIILetter(ch) || ch == '_' || ch == ':'
+}
+
+func isAttrNameChar(ch byte) bool {
+	return isAttrNameStart(ch) || isASCIIDigit(ch) || ch == '.' || ch == '-'
+}
+
+func isASCIIPunct(ch byte) bool {
+	return (ch >= '!' && ch <= '/') || (ch >= ':' && ch <= '@') || (ch >= '[' && ch <= '`') || (ch >= '{' && ch <= '~')
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	liquidTag   = regexp.MustCompile(`\{%-?\s*(link|post_url|include_relative|include)\s+("[^"]*"|'[^']*'|[^\s%]+)[^%]*?-?%\}`)
+	liquidRaw   = regexp.MustCompile(`\{%-?\s*raw\s*-?%\}`)
+	liquidRawTo = regexp.MustCompile(`\{%-?\s*endraw\s*-?%\}`)
+)
+
+// liquidTags returns Jekyll link, post_url, include_relative and include
+// tags found outside code blocks and {% raw %} sections. The tag name is kept
+// in Text because each one resolves against a different directory.
+func liquidTags(doc *document) []Link {
+	raw := liquidRawRanges(doc.text)
+	links := make([]Link, 0)
+	for _, blocks := range [][]inlineBlock{doc.blocks, doc.htmlBlocks} {
+		for _, block := range blocks {
+			text := doc.text[block.start:block.end]
+			for _, m := range liquidTag.FindAllStringSubmatchIndex(text, -1) {
+				start, end := m[4], m[5]
+				if inRanges(raw, block.start+start) {
+					continue
+				}
+				if q := text[start]; q == '"' || q == '\'' {
+					start, end = start+1, end-1
+				}
+				target := text[start:end]
+				if target == "" || strings.Contains(target, "{{") {
+					continue
+				}
+				links = append(links, Link{Kind: LinkLiquid, Text: text[m[2]:m[3]], Destination: target, Offset: block.start + start})
+			}
+		}
+	}
+	return links
+}
+
+func liquidRawRanges(text string) [][2]int {
+	ranges := make([][2]int, 0)
+	for i := 0; i < len(text); {
+		open := liquidRaw.FindStringIndex(text[i:])
+		if open == nil {
+			break
+		}
+		start := i + open[1]
+		end := len(text)
+		if closeAt := liquidRawTo.FindStringIndex(text[start:]); closeAt != nil {
+			end = start + closeAt[0]
+		}
+		ranges = append(ranges, [2]int{start, end})
+		i = end
+	}
+	return ranges
+}
+
+func inRanges(ranges [][2]int, offset int) bool {
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultJSXLinks is the component:attribute list used for MDX files unless
+// configured otherwise.
+const DefaultJSXLinks = "Link:to,a:href"
+
+var esmSpecifier = regexp.MustCompile(`\b(?:from|import)\s*(?:'([^'\n]*)'|"([^"\n]*)")`)
+
+// ParseJSXLinks turns "Component:attribute" entries into the lookup used by
+// MDX parsing. Component names are case-sensitive, as in JSX.
+func ParseJSXLinks(entries []string) (map[string][]string, error) {
+	out := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		name, attr, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		name, attr = strings.TrimSpace(name), strings.ToLower(strings.TrimSpace(attr))
+		if !ok || name == "" || attr == "" {
+			return nil, fmt.Errorf("invalid JSX link %q: want Component:attribute", entry)
+		}
+		out[name] = append(out[name], attr)
+	}
+	return out, nil
+}
+
+// stripMDXSyntax blanks top-level ESM blocks and {/* */} comments so the
+// markdown passes skip them, and returns the relative file imports as links.
+func stripMDXSyntax(content string) (string, []Link) {
+	b := []byte(content)
+	imports := make([]Link, 0)
+	var fenceChar byte
+	fenceLen := 0
+	esmStart := -1
+	for start := 0; start <= len(b); {
+		end := lineEnd(b, start)
+		line := string(b[start:end])
+		switch {
+		case fenceChar != 0:
+			if closingFence(strings.TrimLeft(line, " "), fenceChar, fenceLen) {
+				fenceChar = 0
+			}
+		case esmStart >= 0:
+			if strings.TrimSpace(line) == "" {
+				imports = append(imports, esmImports(content, esmStart, start)...)
+				blankBytes(b, esmStart, start)
+				esmStart = -1
+			}
+		case isESMLine(line):
+			esmStart = start
+		default:
+			if ch, n, ok := openingFence(strings.TrimLeft(line, " ")); ok {
+				fenceChar, fenceLen = ch, n
+				break
+			}
+			end = blankJSXComments(b, start, end)
+		}
+		if end >= len(b) {
+			break
+		}
+		start = end + 1
+	}
+	if esmStart >= 0 {
+		imports = append(imports, esmImports(content, esmStart, len(b))...)
+		blankBytes(b, esmStart, len(b))
+	}
+	return string(b), imports
+}
+
+func isESMLine(line string) bool {
+	for _, keyword := range []string{"import", "export"} {
+		if rest, ok := strings.CutPrefix(line, keyword); ok && rest != "" && strings.ContainsRune(" \t{*'\"", rune(rest[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+// esmImports keeps specifiers that name a relative file with an extension;
+// bare package imports and extensionless component modules are not pages.
+func esmImports(content string, from, to int) []Link {
+	links := make([]Link, 0)
+	for _, m := range esmSpecifier.FindAllStringSubmatchIndex(content[from:to], -1) {
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[4], m[5]
+		}
+		spec := content[from+start : from+end]
+		if !strings.HasPrefix(spec, "./") && !strings.HasPrefix(spec, "../") && !strings.HasPrefix(spec, "/") {
+			continue
+		}
+		if path.Ext(spec) == "" {
+			continue
+		}
+		links = append(links, Link{Kind: LinkImport, Destination: spec, Offset: from + start})
+	}
+	return links
+}
+
+// blankJSXComments blanks every {/* */} comment opening on the line at
+// [start, end) and returns the end of the line where the last one closes.
+func blankJSXComments(b []byte, start, end int) int {
+	for i := start; i < end; {
+		open := bytes.Index(b[i:end], []byte("{/*"))
+		if open < 0 {
+			break
+		}
+		open += i
+		closeAt := bytes.Index(b[open+3:], []byte("*/}"))
+		if closeAt < 0 {
+			blankBytes(b, open, len(b))
+			return len(b)
+		}
+		closeAt += open + 3 + len("*/}")
+		blankBytes(b, open, closeAt)
+		if closeAt > end {
+			end = lineEnd(b, closeAt)
+		}
+		i = closeAt
+	}
+	return end
+}
+
+func lineEnd(b []byte, start int) int {
+	if i := bytes.IndexByte(b[start:], '\n'); i >= 0 {
+		return start + i
+	}
+	return len(b)
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mystRole       = regexp.MustCompile("\\{(doc|ref)\\}`([^`]+)`")
+	mystTarget     = regexp.MustCompile(`(?m)^[ \t]*\(([^()\s]+)\)=[ \t]*$`)
+	mystColonFence = regexp.MustCompile(`^[ \t]*(:{3,})\{toctree\}`)
+)
+
+// mystSyntax returns the Sphinx structure of a MyST document: toctree
+// entries (backtick and colon fences), {doc} and {ref} roles, and the
+// (label)= targets that {ref} roles point at. Toctree entries and doc roles
+// name Sphinx docnames, usually without an extension, so graph resolves them
+// rather than treating them as plain relative paths.
+func mystSyntax(doc *document) ([]Link, []string) {
+	links := make([]Link, 0)
+	labels := make([]string, 0)
+	for _, fence := range doc.fences {
+		if fence.info == "{toctree}" || strings.HasPrefix(fence.info, "{toctree} ") {
+			links = append(links, toctreeEntries(doc.text, fence.start, fence.end)...)
+		}
+	}
+	links = append(links, colonToctrees(doc)...)
+
+	for _, blocks := range [][]inlineBlock{doc.blocks, doc.htmlBlocks} {
+		for _, block := range blocks {
+			text := doc.text[block.start:block.end]
+			for _, m := range mystRole.FindAllStringSubmatchIndex(text, -1) {
+				target, at := text[m[4]:m[5]], m[4]
+				if open, closeAt := strings.LastIndexByte(target, '<'), strings.LastIndexByte(target, '>'); open >= 0 && closeAt > open {
+					at += open + 1
+					target = target[open+1 : closeAt]
+				}
+				links = append(links, Link{Kind: LinkRole, Text: text[m[2]:m[3]], Destination: target, Offset: block.start + at})
+			}
+			for _, m := range mystTarget.FindAllStringSubmatch(text, -1) {
+				labels = append(labels, m[1])
+			}
+		}
+	}
+	return links, labels
+}
+
+// colonToctrees finds ":::{toctree}" fences. They are not CommonMark, so the
+// block parser sees paragraphs; lines inside backtick fences are skipped.
+func colonToctrees(doc *document) []Link {
+	links := make([]Link, 0)
+	lines := splitLines(doc.text)
+	for i := 0; i < len(lines); i++ {
+		m := mystColonFence.FindStringSubmatch(doc.text[lines[i].start:lines[i].end])
+		if m == nil || doc.inFence(lines[i].start) {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !closingFence(strings.TrimSpace(doc.text[lines[end].start:lines[end].end]), ':', len(m[1])) {
+			end++
+		}
+		bodyEnd := len(doc.text)
+		if end < len(lines) {
+			bodyEnd = lines[end].start
+		}
+		links = append(links, toctreeEntries(doc.text, lines[i].end, bodyEnd)...)
+		i = end
+	}
+	return links
+}
+
+func (d *document) inFence(offset int) bool {
+	for _, fence := range d.fences {
+		if offset >= fence.start && offset <= fence.end {
+			return true
+		}
+	}
+	return false
+}
+
+// toctreeEntries reads the body of a toctree directive: ":option:" lines or a
+// "---" delimited option block, then one entry per line, either "docname" or
+// "Title <docname>". With the glob option, entries containing wildcards are
+// marked as patterns.
+func toctreeEntries(text string, start, end int) []Link {
+	links := make([]Link, 0)
+	glob := false
+	inOptions := false
+	for _, line := range splitLines(text[start:end]) {
+		raw := text[start+line.start : start+line.end]
+		entry := strings.TrimSpace(raw)
+		switch {
+		case entry == "":
+			continue
+		case entry == "---" && len(links) == 0:
+			inOptions = !inOptions
+			continue
+		case inOptions:
+			if key, value, _ := strings.Cut(entry, ":"); strings.TrimSpace(key) == "glob" && strings.TrimSpace(value) != "false" {
+				glob = true
+			}
+			continue
+		case strings.HasPrefix(entry, ":"):
+			if strings.HasPrefix(entry, ":glob:") {
+				glob = true
+			}
+			continue
+		}
+
+		at := start + line.start + strings.Index(raw, entry)
+		if open, closeAt := strings.LastIndexByte(entry, '<'), strings.LastIndexByte(entry, '>'); open >= 0 && closeAt > open {
+			at += open + 1
+			entry = entry[open+1 : closeAt]
+		}
+		if entry == "self" {
+			continue
+		}
+		link := Link{Kind: LinkToctree, Destination: entry, Offset: at}
+		if glob && strings.ContainsAny(entry, "*?[") {
+			link.Text = "glob"
+		}
+		links = append(links, link)
+	}
+	return links
+}
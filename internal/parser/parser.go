@@ -3,182 +3,274 @@ package parser
 import (
 	"html"
 	"net/url"
+	"path"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
-	"gorphan/internal/pathutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
 )
 
-var (
-	inlineLinkRe = regexp.MustCompile(`\[[^\]]+\]\(([^)]+)\)`)
-	refDefRe     = regexp.MustCompile(`(?m)^\s{0,3}\[([^\]]+)\]:\s*(\S+)`)
-	refLinkRe    = regexp.MustCompile(`\[[^\]]+\]\[([^\]]*)\]`)
-	wikiLinkRe   = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+type LinkKind string
+
+const (
+	LinkInline    LinkKind = "inline"
+	LinkReference LinkKind = "reference"
+	LinkImage     LinkKind = "image"
+	LinkWiki      LinkKind = "wikilink"
+	LinkEmbed     LinkKind = "embed"
+	LinkHTML      LinkKind = "html"
+	LinkImport    LinkKind = "import"
+	LinkInclude   LinkKind = "include"
+	LinkRole      LinkKind = "role"
+	LinkXref      LinkKind = "xref"
+	LinkShortcode LinkKind = "shortcode"
+	LinkSection   LinkKind = "section"
+	LinkLiquid    LinkKind = "liquid"
+	LinkNav       LinkKind = "nav"
+	LinkToctree   LinkKind = "toctree"
 )
 
+type Link struct {
+	Kind        LinkKind
+	Text        string
+	Destination string
+	Target      string
+	Fragment    string
+	Line        int
+	Column      int
+	Offset      int
+}
+
 func ExtractLocalMarkdownLinks(content string, extensions []string) []string {
-	extSet := pathutil.ExtensionSet(extensions)
-	refDefs := parseReferenceDefinitions(content)
 	seen := make(map[string]struct{})
-	links := make([]string, 0)
-
-	for _, match := range inlineLinkRe.FindAllStringSubmatch(content, -1) {
-		if len(match) < 2 {
-			continue
-		}
-		target, ok := normalizeMarkdownTarget(match[1], extSet)
-		if !ok {
-			continue
-		}
-		if _, exists := seen[target]; exists {
+	targets := make([]string, 0)
+	for _, link := range ExtractLinks(content, extensions) {
+		if _, exists := seen[link.Target]; exists {
 			continue
 		}
-		seen[target] = struct{}{}
-		links = append(links, target)
+		seen[link.Target] = struct{}{}
+		targets = append(targets, link.Target)
 	}
+	return targets
+}
 
-	for _, match := range refLinkRe.FindAllStringSubmatch(content, -1) {
-		if len(match) < 2 {
-			continue
-		}
-		label := strings.TrimSpace(match[1])
-		if label == "" {
+func ExtractLinks(content string, extensions []string) []Link {
+	extSet := pathutil.ExtensionSet(extensions)
+	links := make([]Link, 0)
+	for _, link := range ExtractLocalLinks(content) {
+		if _, ok := extSet[strings.ToLower(path.Ext(link.Target))]; !ok {
 			continue
 		}
+		links = append(links, link)
+	}
+	return links
+}
 
-		raw, ok := refDefs[strings.ToLower(label)]
-		if !ok {
-			continue
-		}
-		target, ok := normalizeMarkdownTarget(raw, extSet)
-		if !ok {
+type Heading struct {
+	Text  string
+	ID    string
+	Level int
+	Line  int
+}
+
+type Document struct {
+	Links       []Link
+	Headings    []Heading
+	Anchors     []string
+	BlockIDs    []string
+	Labels      []string
+	FrontMatter FrontMatter
+}
+
+// Options enables syntax beyond CommonMark.
+type Options struct {
+	// MDX strips ESM import/export blocks; relative file imports become
+	// links.
+	MDX bool
+	// JSXLinks maps MDX component names to the attributes holding link
+	// targets.
+	JSXLinks map[string][]string
+	// Hugo extracts ref/relref shortcodes.
+	Hugo bool
+	// Jekyll extracts Liquid link, post_url and include tags.
+	Jekyll bool
+	// MyST extracts Sphinx toctree directives, {doc}/{ref} roles and
+	// (label)= targets.
+	MyST bool
+	// Includes lists the include directive syntaxes to read.
+	Includes []IncludePattern
+}
+
+func Parse(content string) Document {
+	return ParseWith(content, Options{})
+}
+
+func ParseWith(content string, opts Options) Document {
+	positions := newLineIndex(content)
+	var frontMatter FrontMatter
+	if delim, body, end, ok := splitFrontMatter(content); ok {
+		frontMatter = parseFrontMatter(delim, body)
+		b := []byte(content)
+		blankBytes(b, 0, end)
+		content = string(b)
+	}
+	// Links found by the MDX, Hugo, Jekyll, MyST and include passes are merged into the markdown
+	// links in source order.
+	var extra []Link
+	if opts.MDX {
+		content, extra = stripMDXSyntax(content)
+	}
+	doc := parseDocument(content)
+	links, anchors := extractLinks(doc, opts)
+	if opts.Hugo {
+		extra = append(extra, hugoShortcodes(doc)...)
+	}
+	if opts.Jekyll {
+		extra = append(extra, liquidTags(doc)...)
+	}
+	if len(opts.Includes) > 0 {
+		extra = append(extra, includeDirectives(doc, opts.Includes)...)
+	}
+	var labels []string
+	if opts.MyST {
+		var myst []Link
+		myst, labels = mystSyntax(doc)
+		extra = append(extra, myst...)
+		anchors = append(anchors, labels...)
+	}
+	if len(extra) > 0 {
+		links = append(extra, links...)
+		sort.SliceStable(links, func(i, j int) bool { return links[i].Offset < links[j].Offset })
+	}
+
+	out := Document{Links: resolveLinks(positions, links), Anchors: anchors, Labels: labels, FrontMatter: frontMatter}
+
+	for _, block := range doc.blocks {
+		if block.kind != blockHeading {
 			continue
 		}
-		if _, exists := seen[target]; exists {
-			continue
+		text, id := headingText(doc.text[block.start:block.end])
+		line, _ := positions.position(block.start)
+		out.Headings = append(out.Headings, Heading{Text: text, ID: id, Level: block.level, Line: line})
+	}
+	for _, block := range doc.blocks {
+		out.BlockIDs = append(out.BlockIDs, blockIDs(doc.text[block.start:block.end])...)
+	}
+	return out
+}
+
+// blankBytes replaces b[from:to] with spaces, keeping line breaks so link
+// offsets still map to the original lines and columns.
+func blankBytes(b []byte, from, to int) {
+	for i := from; i < to; i++ {
+		if b[i] != '\n' && b[i] != '\r' {
+			b[i] = ' '
 		}
-		seen[target] = struct{}{}
-		links = append(links, target)
 	}
+}
 
-	for _, match := range wikiLinkRe.FindAllStringSubmatch(content, -1) {
-		if len(match) < 2 {
-			continue
+// resolveLinks normalizes each destination into Target and Fragment, drops
+// external links, and fills in source positions from the offsets.
+func resolveLinks(positions lineIndex, links []Link) []Link {
+	out := make([]Link, 0, len(links))
+	for _, link := range links {
+		var target, fragment string
+		var ok bool
+		switch {
+		case link.IsWiki():
+			target, fragment, ok = normalizeWikiTarget(link.Destination)
+		case link.IsLabelRef():
+			target = strings.TrimSpace(link.Destination)
+			ok = target != ""
+		default:
+			target, fragment, ok = normalizeLocalTarget(link.Destination)
 		}
-		target, ok := normalizeWikiTarget(match[1], extSet)
 		if !ok {
 			continue
 		}
-		if _, exists := seen[target]; exists {
-			continue
-		}
-		seen[target] = struct{}{}
-		links = append(links, target)
+		link.Target = target
+		link.Fragment = fragment
+		link.Line, link.Column = positions.position(link.Offset)
+		out = append(out, link)
 	}
+	return out
+}
 
-	return links
+func (l Link) IsWiki() bool {
+	return l.Kind == LinkWiki || l.Kind == LinkEmbed
 }
 
-func parseReferenceDefinitions(content string) map[string]string {
-	matches := refDefRe.FindAllStringSubmatch(content, -1)
-	defs := make(map[string]string, len(matches))
-	for _, match := range matches {
-		if len(match) < 3 {
-			continue
-		}
-		label := strings.ToLower(strings.TrimSpace(match[1]))
-		dest := strings.TrimSpace(match[2])
-		if label == "" || dest == "" {
-			continue
-		}
-		defs[label] = dest
-	}
-	return defs
+// IsLabelRef reports whether the link is a Sphinx {ref} role, whose target is
+// a label name rather than a path.
+func (l Link) IsLabelRef() bool {
+	return l.Kind == LinkRole && l.Text == "ref"
+}
+
+func ExtractLocalLinks(content string) []Link {
+	return Parse(content).Links
 }
 
-func normalizeMarkdownTarget(raw string, extSet map[string]struct{}) (string, bool) {
-	target := parseDestination(raw)
+func normalizeLocalTarget(raw string) (string, string, bool) {
+	target := strings.TrimSpace(raw)
 	if target == "" {
-		return "", false
+		return "", "", false
 	}
 	target = decodeBasicEscapes(target)
+	fragment := ""
+	if i := strings.Index(target, "#"); i >= 0 {
+		fragment = target[i+1:]
+	}
 	target = stripQueryAndFragment(target)
 	target = strings.TrimSpace(target)
 	if target == "" {
-		return "", false
+		if fragment == "" || strings.HasPrefix(strings.TrimSpace(raw), "?") {
+			return "", "", false
+		}
+		return "", fragment, true
 	}
 
 	if isExternalTarget(target) {
-		return "", false
-	}
-	if strings.HasPrefix(target, "/") {
-		return "", false
+		return "", "", false
 	}
 
+	isDir := strings.HasSuffix(target, "/") || strings.HasSuffix(target, "\\")
 	target = filepath.ToSlash(filepath.Clean(target))
-	ext := strings.ToLower(filepath.Ext(target))
-	if ext == "" {
-		return "", false
-	}
-	if _, ok := extSet[ext]; !ok {
-		return "", false
+	if isDir && target != "/" {
+		target += "/"
 	}
-	return target, true
+	return target, fragment, true
 }
 
-func normalizeWikiTarget(raw string, extSet map[string]struct{}) (string, bool) {
+func normalizeWikiTarget(raw string) (string, string, bool) {
 	target := strings.TrimSpace(raw)
-	if target == "" {
-		return "", false
+	fragment := ""
+	if i := strings.IndexAny(target, "#^"); i >= 0 {
+		target, fragment = strings.TrimSpace(target[:i]), wikiFragment(target[i:])
 	}
-	if i := strings.Index(target, "|"); i >= 0 {
-		target = strings.TrimSpace(target[:i])
-	}
-	if target == "" || strings.HasPrefix(target, "#") {
-		return "", false
+	if target == "" {
+		return "", fragment, fragment != ""
 	}
 	if filepath.Ext(target) == "" {
 		target += ".md"
 	}
-	return normalizeMarkdownTarget(target, extSet)
+	target, _, ok := normalizeLocalTarget(target)
+	return target, fragment, ok
 }
 
-func parseDestination(raw string) string {
-	dest := strings.TrimSpace(raw)
-	if dest == "" {
-		return ""
-	}
-
-	if strings.HasPrefix(dest, "<") {
-		if end := strings.Index(dest, ">"); end > 1 {
-			dest = strings.TrimSpace(dest[1:end])
-		}
-	}
-
-	for i := 0; i < len(dest); i++ {
-		ch := dest[i]
-		if ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r' {
-			continue
-		}
-
-		if i > 0 && dest[i-1] == '\\' {
-			continue
-		}
-		dest = dest[:i]
-		break
+// wikiFragment reduces "#Parent#Child", "#^block" and "^block" suffixes to
+// the innermost heading text or a "^"-prefixed block id.
+func wikiFragment(raw string) string {
+	if strings.HasPrefix(raw, "^") {
+		return strings.TrimSpace(raw)
 	}
-
-	return strings.TrimSpace(dest)
+	raw = raw[strings.LastIndex(raw, "#")+1:]
+	return strings.TrimSpace(raw)
 }
 
 func decodeBasicEscapes(value string) string {
-	decoded := strings.ReplaceAll(value, `\ `, " ")
-	decoded = strings.ReplaceAll(decoded, `\(`, "(")
-	decoded = strings.ReplaceAll(decoded, `\)`, ")")
-	decoded = strings.ReplaceAll(decoded, `\[`, "[")
-	decoded = strings.ReplaceAll(decoded, `\]`, "]")
-	decoded = strings.ReplaceAll(decoded, `\#`, "#")
+	decoded := unescapeBackslashes(value)
 	decoded = html.UnescapeString(decoded)
 
 	if unescaped, err := url.PathUnescape(decoded); err == nil {
@@ -187,6 +279,21 @@ func decodeBasicEscapes(value string) string {
 	return decoded
 }
 
+func unescapeBackslashes(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	b.Grow(len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) && (isASCIIPunct(value[i+1]) || value[i+1] == ' ') {
+			i++
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
 func stripQueryAndFragment(value string) string {
 	cut := len(value)
 	if i := strings.Index(value, "#"); i >= 0 && i < cut {
@@ -198,9 +305,41 @@ func stripQueryAndFragment(value string) string {
 	return value[:cut]
 }
 
+type lineIndex struct {
+	content string
+	starts  []int
+}
+
+func newLineIndex(content string) lineIndex {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return lineIndex{content: content, starts: starts}
+}
+
+func (l lineIndex) position(offset int) (line int, column int) {
+	line = sort.Search(len(l.starts), func(i int) bool { return l.starts[i] > offset })
+	lineStart := l.starts[line-1]
+	return line, utf8.RuneCountInString(l.content[lineStart:offset]) + 1
+}
+
 func isExternalTarget(value string) bool {
-	lower := strings.ToLower(value)
-	return strings.HasPrefix(lower, "http://") ||
-		strings.HasPrefix(lower, "https://") ||
-		strings.HasPrefix(lower, "mailto:")
+	if strings.HasPrefix(value, "//") {
+		return true
+	}
+	colon := strings.IndexByte(value, ':')
+	if colon < 2 || colon > 32 {
+		return false
+	}
+	for i := 0; i < colon; i++ {
+		ch := value[i]
+		if isASCIILetter(ch) || (i > 0 && (isASCIIDigit(ch) || ch == '+' || ch == '.' || ch == '-')) {
+			continue
+		}
+		return false
+	}
+	return true
 }
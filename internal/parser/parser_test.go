@@ -16,6 +16,7 @@ func TestExtractLocalMarkdownLinks(t *testing.T) {
 [escaped](./guide/my\ file.md)
 
 [ref-doc][doc]
+
 [doc]: ./ref/readme.markdown#top
 [[WikiPage]]
 [[nested/Guide|Guide Page]]
@@ -35,9 +36,8 @@ func TestExtractLocalMarkdownLinks(t *testing.T) {
 	}
 }
 
-func TestExtractLocalMarkdownLinks_IgnoresAbsoluteAndUnknownExt(t *testing.T) {
+func TestExtractLocalMarkdownLinks_IgnoresUnknownExt(t *testing.T) {
 	content := `
-[root](/docs/root.md)
 [pdf](./docs/manual.pdf)
 [[#Section]]
 `
@@ -47,3 +47,610 @@ func TestExtractLocalMarkdownLinks_IgnoresAbsoluteAndUnknownExt(t *testing.T) {
 		t.Fatalf("expected no links, got: %#v", got)
 	}
 }
+
+func TestExtractLocalMarkdownLinks_KeepsRootRelativeTargets(t *testing.T) {
+	content := `
+[root](/docs/root.md)
+[dotted](/docs/../guide/./setup.md#install)
+[protocol-relative](//example.com/docs/root.md)
+`
+
+	got := ExtractLocalMarkdownLinks(content, []string{".md"})
+	want := []string{"/docs/root.md", "/guide/setup.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected links\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestExtractLocalMarkdownLinks_SkipsCodeAndComments(t *testing.T) {
+	content := "# Tutorial\n" +
+		"\n" +
+		"```md\n" +
+		"[fenced](./fenced.md)\n" +
+		"```\n" +
+		"\n" +
+		"~~~~\n" +
+		"[tilde](./tilde.md)\n" +
+		"~~~~\n" +
+		"\n" +
+		"    [indented](./indented.md)\n" +
+		"\n" +
+		"Use `[span](./span.md)` in your docs.\n" +
+		"\n" +
+		"<!--\n" +
+		"[comment](./comment.md)\n" +
+		"-->\n" +
+		"\n" +
+		"Inline <!-- [hidden](./hidden.md) --> comment and [real](./real.md).\n" +
+		"\n" +
+		"<div>\n" +
+		"[html](./html.md)\n" +
+		"</div>\n" +
+		"\n" +
+		"\\[escaped](./escaped.md)\n"
+
+	got := ExtractLocalMarkdownLinks(content, []string{".md"})
+	want := []string{"real.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected links\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestExtractLocalMarkdownLinks_CommonMarkInlines(t *testing.T) {
+	content := `
+[a [b] c](./nested.md)
+[outer [inner](./inner.md)](./outer.md)
+[spaced](<./my file.md> "Title")
+[parens](./a(b).md)
+![image](./diagram.md)
+<https://example.com/auto.md>
+[multi
+line](./multiline.md)
+[collapsed][]
+[Shortcut]
+
+[collapsed]: ./collapsed.md
+[shortcut]: <./shortcut.md> 'title'
+[shortcut]: ./duplicate.md
+`
+
+	got := ExtractLocalMarkdownLinks(content, []string{".md"})
+	want := []string{
+		"nested.md",
+		"inner.md",
+		"my file.md",
+		"a(b).md",
+		"diagram.md",
+		"multiline.md",
+		"collapsed.md",
+		"shortcut.md",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected links\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestExtractLocalMarkdownLinks_ContainerBlocks(t *testing.T) {
+	content := `
+> Quote with [quoted](./quoted.md)
+> and a lazy
+continuation [lazy](./lazy.md)
+
+- item [listed](./listed.md)
+
+      [list-code](./list-code.md)
+
+  continued [continued](./continued.md)
+
+1. step
+   ` + "```" + `
+   [list-fence](./list-fence.md)
+   ` + "```" + `
+
+> ` + "```" + `
+> [quote-fence](./quote-fence.md)
+> ` + "```" + `
+
+Heading [setext](./setext.md)
+===
+`
+
+	got := ExtractLocalMarkdownLinks(content, []string{".md"})
+	want := []string{
+		"quoted.md",
+		"lazy.md",
+		"listed.md",
+		"continued.md",
+		"setext.md",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected links\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestExtractLocalMarkdownLinks_ReferenceDefinitionsInCodeIgnored(t *testing.T) {
+	content := "[doc][ref]\n\n```\n[ref]: ./fenced.md\n```\n"
+
+	got := ExtractLocalMarkdownLinks(content, []string{".md"})
+	if len(got) != 0 {
+		t.Fatalf("expected no links, got: %#v", got)
+	}
+}
+
+func TestExtractLinks_PositionsAndKinds(t *testing.T) {
+	content := "# Title\n" +
+		"\n" +
+		"Intro [Guide](./guide.md \"Guide\") and ![chart](./chart.md).\n" +
+		"日本語 [[Notes|My notes]]\n" +
+		"\n" +
+		"See [the ref][ref].\n" +
+		"\n" +
+		"[ref]: <./ref.md>\n"
+
+	got := ExtractLinks(content, []string{".md"})
+	want := []Link{
+		{Kind: LinkInline, Text: "Guide", Destination: "./guide.md", Target: "guide.md", Line: 3, Column: 7, Offset: 15},
+		{Kind: LinkImage, Text: "chart", Destination: "./chart.md", Target: "chart.md", Line: 3, Column: 39, Offset: 47},
+		{Kind: LinkWiki, Text: "My notes", Destination: "Notes", Target: "Notes.md", Line: 4, Column: 5, Offset: 79},
+		{Kind: LinkReference, Text: "the ref", Destination: "./ref.md", Target: "ref.md", Line: 6, Column: 5, Offset: 103},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected links\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestExtractLocalLinks_ImagesAndHTML(t *testing.T) {
+	content := `
+![Logo](./img/logo.png)
+Inline <img src="./img/inline.svg" alt="Inline"> image.
+[Manual](./files/manual.pdf)
+![remote](https://example.com/remote.png)
+![data](data:image/png;base64,AAAA)
+
+<p align="center">
+  <img alt="Banner" src='img/banner.jpg' />
+  <!-- <img src="img/commented.png"> -->
+</p>
+
+` + "```html\n<img src=\"img/fenced.png\">\n```\n"
+
+	var got []string
+	for _, link := range ExtractLocalLinks(content) {
+		got = append(got, string(link.Kind)+":"+link.Target)
+	}
+	want := []string{
+		"image:img/logo.png",
+		"html:img/inline.svg",
+		"inline:files/manual.pdf",
+		"html:img/banner.jpg",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected links\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestExtractLocalLinks_KeepsExtensionlessAndDirectoryTargets(t *testing.T) {
+	content := `
+[Setup](./setup)
+[API](./api/)
+[Home](/)
+[Here](./)
+[Up](..)
+`
+
+	var got []string
+	for _, link := range ExtractLocalLinks(content) {
+		got = append(got, link.Target)
+	}
+	want := []string{"setup", "api/", "/", "./", ".."}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestParse_HeadingsAnchorsAndFragments(t *testing.T) {
+	content := "# Getting *Started* with `go test`\n\n" +
+		"Setext [Link](./x.md) Title\n---\n\n" +
+		"## Custom {#my-id .note}\n\n" +
+		"> ### Quoted __init__ snake_case\n\n" +
+		"```\n# not a heading\n```\n\n" +
+		"<a id=\"legacy\"></a>\n\n" +
+		"Para with <span id=\"inline-id\">x</span> and <a name=\"old\">y</a>.\n\n" +
+		"[same](#custom) [other](./guide.md#install) [query](./guide.md?x=1#top) [plain](./guide.md)\n"
+
+	doc := Parse(content)
+
+	wantHeadings := []Heading{
+		{Text: "Getting Started with go test", Level: 1, Line: 1},
+		{Text: "Setext Link Title", Level: 2, Line: 3},
+		{Text: "Custom", ID: "my-id", Level: 2, Line: 6},
+		{Text: "Quoted init snake_case", Level: 3, Line: 8},
+	}
+	if !reflect.DeepEqual(doc.Headings, wantHeadings) {
+		t.Fatalf("unexpected headings\nwant: %#v\n got: %#v", wantHeadings, doc.Headings)
+	}
+
+	wantAnchors := []string{"legacy", "inline-id", "old"}
+	if !reflect.DeepEqual(doc.Anchors, wantAnchors) {
+		t.Fatalf("unexpected anchors\nwant: %#v\n got: %#v", wantAnchors, doc.Anchors)
+	}
+
+	var got [][2]string
+	for _, link := range doc.Links {
+		got = append(got, [2]string{link.Target, link.Fragment})
+	}
+	want := [][2]string{{"x.md", ""}, {"", "custom"}, {"guide.md", "install"}, {"guide.md", "top"}, {"guide.md", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets and fragments\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestParse_ObsidianEmbedsAndRefs(t *testing.T) {
+	content := "![[diagram.png]] ![[note]] [[note#Setup: Linux]] [[note#Guide#Install|install]] [[note^abc-1]] [[note#^def]] [[#Local]] [[^ghi]]\n\n" +
+		"A paragraph with an id ^para-1\n\n" +
+		"- item one\n- item two ^item-2\n\n" +
+		"not an id^x and price ^5$\n\n" +
+		"```\ncode ^fenced\n```\n"
+
+	doc := Parse(content)
+
+	type linkSummary struct {
+		Kind     LinkKind
+		Target   string
+		Fragment string
+	}
+	var got []linkSummary
+	for _, link := range doc.Links {
+		got = append(got, linkSummary{link.Kind, link.Target, link.Fragment})
+	}
+	want := []linkSummary{
+		{LinkEmbed, "diagram.png", ""},
+		{LinkEmbed, "note.md", ""},
+		{LinkWiki, "note.md", "Setup: Linux"},
+		{LinkWiki, "note.md", "Install"},
+		{LinkWiki, "note.md", "^abc-1"},
+		{LinkWiki, "note.md", "^def"},
+		{LinkWiki, "", "Local"},
+		{LinkWiki, "", "^ghi"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected wiki links\nwant: %#v\n got: %#v", want, got)
+	}
+
+	wantIDs := []string{"para-1", "item-2"}
+	if !reflect.DeepEqual(doc.BlockIDs, wantIDs) {
+		t.Fatalf("unexpected block ids\nwant: %#v\n got: %#v", wantIDs, doc.BlockIDs)
+	}
+}
+
+func TestParse_FrontMatter(t *testing.T) {
+	yaml := "---\ntitle: \"Guide: Setup\"\naliases:\n  - Setup Guide\n  - 'Install'\ndraft: true\ngorphan: { orphan: allowed }\n---\n[a](./a.md)\n"
+	doc := Parse(yaml)
+	want := FrontMatter{Aliases: []string{"Setup Guide", "Install"}, Draft: true, OrphanAllowed: true}
+	if !reflect.DeepEqual(doc.FrontMatter, want) {
+		t.Fatalf("unexpected yaml front matter\nwant: %#v\n got: %#v", want, doc.FrontMatter)
+	}
+	if len(doc.Links) != 1 || doc.Links[0].Target != "a.md" || doc.Links[0].Line != 9 {
+		t.Fatalf("expected body link with original line number, got %#v", doc.Links)
+	}
+
+	toml := "+++\naliases = [\"Old Name\", \"Other\"]\ndraft = false\n\n[gorphan]\norphan = \"allowed\"\n+++\n# Title\n"
+	doc = Parse(toml)
+	want = FrontMatter{Aliases: []string{"Old Name", "Other"}, OrphanAllowed: true}
+	if !reflect.DeepEqual(doc.FrontMatter, want) {
+		t.Fatalf("unexpected toml front matter\nwant: %#v\n got: %#v", want, doc.FrontMatter)
+	}
+
+	doc = Parse("text\n---\naliases: [x]\n---\n")
+	if !reflect.DeepEqual(doc.FrontMatter, FrontMatter{}) {
+		t.Fatalf("expected front matter only at document start, got %#v", doc.FrontMatter)
+	}
+
+	for _, value := range []string{"yes", "\"True \"", "on", "Y"} {
+		doc = Parse("---\ndraft: " + value + "\ngorphan: { orphan: " + value + " }\n---\n")
+		want = FrontMatter{Draft: true, OrphanAllowed: true}
+		if !reflect.DeepEqual(doc.FrontMatter, want) {
+			t.Fatalf("expected %s to be truthy\nwant: %#v\n got: %#v", value, want, doc.FrontMatter)
+		}
+	}
+	doc = Parse("---\ndraft: no\n---\n")
+	if doc.FrontMatter.Draft {
+		t.Fatalf("expected draft: no to leave the page published")
+	}
+}
+
+func TestParseWith_MDX(t *testing.T) {
+	content := "import Tabs from '@theme/Tabs'\n" +
+		"import Partial from './_partial.mdx'\n" +
+		"import {\n  Helper,\n} from \"../shared/helper.md\"\n" +
+		"import Card from './Card'\n\n" +
+		"# Title\n\n" +
+		"<Tabs>\n[plain](./plain.md)\n<Link to=\"./guide.md\">Guide</Link>\n</Tabs>\n\n" +
+		"<Card {...props} href={\"./card.md\"} onClick={() => go('./nope.md')} />\n" +
+		"<a href={`./about.md`}>About</a> <link to=\"./lower.md\" />\n\n" +
+		"{/* [hidden](./hidden.md) */}\n\n" +
+		"```js\nimport X from './code.mdx'\n```\n"
+
+	jsxLinks, err := ParseJSXLinks([]string{"Link:to", "a:href", "Card:href"})
+	if err != nil {
+		t.Fatalf("parse jsx links: %v", err)
+	}
+	doc := ParseWith(content, Options{MDX: true, JSXLinks: jsxLinks})
+
+	type linkSummary struct {
+		Kind   LinkKind
+		Target string
+		Line   int
+	}
+	var got []linkSummary
+	for _, link := range doc.Links {
+		got = append(got, linkSummary{link.Kind, link.Target, link.Line})
+	}
+	want := []linkSummary{
+		{LinkImport, "_partial.mdx", 2},
+		{LinkImport, "../shared/helper.md", 5},
+		{LinkInline, "plain.md", 11},
+		{LinkHTML, "guide.md", 12},
+		{LinkHTML, "card.md", 15},
+		{LinkHTML, "about.md", 16},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected mdx links\nwant: %#v\n got: %#v", want, got)
+	}
+	if len(doc.Headings) != 1 || doc.Headings[0].Text != "Title" {
+		t.Fatalf("expected heading after ESM block, got %#v", doc.Headings)
+	}
+
+	if _, err := ParseJSXLinks([]string{"Link"}); err == nil {
+		t.Fatalf("expected error for entry without attribute")
+	}
+}
+
+type extractedLink struct {
+	Kind     LinkKind
+	Target   string
+	Fragment string
+	Line     int
+}
+
+func summarizeLinks(links []Link) []extractedLink {
+	out := make([]extractedLink, 0, len(links))
+	for _, link := range links {
+		out = append(out, extractedLink{link.Kind, link.Target, link.Fragment, link.Line})
+	}
+	return out
+}
+
+func TestRSTExtractor(t *testing.T) {
+	content := "Getting Started\n===============\n\n" +
+		".. _setup-label:\n\n" +
+		"See :doc:`install` and :doc:`the API <../api/index>` or `Guide <guide.rst#usage>`_.\n" +
+		"Named `target <other_>`_ and ``:doc:`literal``` are ignored.\n\n" +
+		".. include:: snippets/note.rst\n" +
+		".. image:: img/logo.png\n" +
+		".. _faq: faq.rst\n\n" +
+		"Example::\n\n    :doc:`in-literal`\n\n" +
+		".. code-block:: rst\n\n   `x <code.rst>`_\n\n" +
+		".. a comment\n   :doc:`commented`\n\n" +
+		"Next Step\n---------\n"
+
+	doc := RSTExtractor{}.Extract(content)
+	want := []extractedLink{
+		{LinkRole, "install.rst", "", 6},
+		{LinkRole, "../api/index.rst", "", 6},
+		{LinkInline, "guide.rst", "usage", 6},
+		{LinkInclude, "snippets/note.rst", "", 9},
+		{LinkImage, "img/logo.png", "", 10},
+		{LinkReference, "faq.rst", "", 11},
+	}
+	if got := summarizeLinks(doc.Links); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected rst links\nwant: %#v\n got: %#v", want, got)
+	}
+	wantHeadings := []Heading{
+		{Text: "Getting Started", ID: "getting-started", Level: 1, Line: 1},
+		{Text: "Next Step", ID: "next-step", Level: 2, Line: 24},
+	}
+	if !reflect.DeepEqual(doc.Headings, wantHeadings) {
+		t.Fatalf("unexpected rst headings\nwant: %#v\n got: %#v", wantHeadings, doc.Headings)
+	}
+	if !reflect.DeepEqual(doc.Anchors, []string{"setup-label"}) {
+		t.Fatalf("unexpected rst anchors: %#v", doc.Anchors)
+	}
+}
+
+func TestAsciiDocExtractor(t *testing.T) {
+	content := "= Manual\n\n" +
+		"[[install]]\n== Install Guide\n\n" +
+		"See xref:setup.adoc#linux[Linux], xref:usage#cli[CLI] and <<install,above>>.\n" +
+		"Also <<faq.adoc#,FAQ>>, link:notes/todo.adoc[todo] and image:icon.png[].\n\n" +
+		"include::partials/intro.adoc[]\n\n" +
+		"----\nxref:in-listing.adoc[]\ninclude::code/sample.adoc[]\n----\n\n" +
+		"////\ninclude::commented.adoc[]\n////\n" +
+		"// xref:line-comment.adoc[]\n" +
+		"== Next Steps\n"
+
+	doc := AsciiDocExtractor{}.Extract(content)
+	want := []extractedLink{
+		{LinkXref, "setup.adoc", "linux", 6},
+		{LinkXref, "usage.adoc", "cli", 6},
+		{LinkXref, "", "install", 6},
+		{LinkXref, "faq.adoc", "", 7},
+		{LinkInline, "notes/todo.adoc", "", 7},
+		{LinkImage, "icon.png", "", 7},
+		{LinkInclude, "partials/intro.adoc", "", 9},
+		{LinkInclude, "code/sample.adoc", "", 13},
+	}
+	if got := summarizeLinks(doc.Links); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected asciidoc links\nwant: %#v\n got: %#v", want, got)
+	}
+	wantHeadings := []Heading{
+		{Text: "Manual", ID: "_manual", Level: 1, Line: 1},
+		{Text: "Install Guide", ID: "install", Level: 2, Line: 4},
+		{Text: "Next Steps", ID: "_next_steps", Level: 2, Line: 20},
+	}
+	if !reflect.DeepEqual(doc.Headings, wantHeadings) {
+		t.Fatalf("unexpected asciidoc headings\nwant: %#v\n got: %#v", wantHeadings, doc.Headings)
+	}
+}
+
+func TestExtractors_For(t *testing.T) {
+	extractors := DefaultExtractors(Options{})
+	if _, ok := extractors.For("docs/Guide.RST").(RSTExtractor); !ok {
+		t.Fatalf("expected rst extractor for .RST")
+	}
+	if _, ok := extractors.For("docs/guide.adoc").(AsciiDocExtractor); !ok {
+		t.Fatalf("expected asciidoc extractor for .adoc")
+	}
+	if m, ok := extractors.For("docs/page.mdx").(MarkdownExtractor); !ok || !m.Options.MDX {
+		t.Fatalf("expected mdx markdown extractor for .mdx")
+	}
+	if m, ok := extractors.For("docs/page.md").(MarkdownExtractor); !ok || m.Options.MDX {
+		t.Fatalf("expected plain markdown extractor for .md")
+	}
+}
+
+func TestParseWith_HugoShortcodes(t *testing.T) {
+	content := "See [install]({{< ref \"guide/install.md\" >}}) and {{% relref path=\"setup#linux\" lang=\"en\" %}}.\n\n" +
+		"{{< ref `/about` >}} {{</* ref \"escaped.md\" */>}}\n\n" +
+		"```\n{{< ref \"in-code.md\" >}}\n```\n"
+
+	doc := ParseWith(content, Options{Hugo: true})
+	want := []extractedLink{
+		{LinkShortcode, "guide/install.md", "", 1},
+		{LinkShortcode, "setup", "linux", 1},
+		{LinkShortcode, "/about", "", 3},
+	}
+	if got := summarizeLinks(doc.Links); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected hugo links\nwant: %#v\n got: %#v", want, got)
+	}
+	if links := Parse(content).Links; len(links) != 0 {
+		t.Fatalf("expected shortcodes to be ignored outside hugo mode, got %#v", links)
+	}
+}
+
+func TestParseWith_JekyllLiquidTags(t *testing.T) {
+	content := "---\npermalink: /docs/setup/\n---\n" +
+		"See [setup]({% link _docs/setup.md %}) and {% post_url 2021-01-01-hello %}.\n\n" +
+		"{% include_relative snippets/note.md %} {%- include \"callout.md\" param=1 -%}\n\n" +
+		"{% raw %}{% link _docs/raw.md %}{% endraw %} {% link {{ page.next }} %}\n\n" +
+		"```\n{% include_relative in-code.md %}\n```\n"
+
+	doc := ParseWith(content, Options{Jekyll: true})
+	if doc.FrontMatter.Permalink != "/docs/setup/" {
+		t.Fatalf("unexpected permalink: %q", doc.FrontMatter.Permalink)
+	}
+	type liquidSummary struct {
+		Tag    string
+		Target string
+		Line   int
+	}
+	var got []liquidSummary
+	for _, link := range doc.Links {
+		if link.Kind != LinkLiquid {
+			t.Fatalf("unexpected link kind %q for %q", link.Kind, link.Destination)
+		}
+		got = append(got, liquidSummary{link.Text, link.Target, link.Line})
+	}
+	want := []liquidSummary{
+		{"link", "_docs/setup.md", 4},
+		{"post_url", "2021-01-01-hello", 4},
+		{"include_relative", "snippets/note.md", 6},
+		{"include", "callout.md", 6},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected liquid tags\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestParseWith_MySTToctreeAndRoles(t *testing.T) {
+	content := "(intro-label)=\n# Intro\n\n" +
+		"```{toctree}\n:maxdepth: 2\n:glob:\n\ninstall\nTutorials <tutorials/*>\nself\n```\n\n" +
+		":::{toctree}\n---\ncaption: Reference\n---\n/reference/api\n:::\n\n" +
+		"See {doc}`the guide <guide/setup>` and {ref}`sec:intro`.\n\n" +
+		"````markdown\n```{toctree}\nnot-an-entry\n```\n````\n"
+
+	doc := ParseWith(content, Options{MyST: true})
+	type mystSummary struct {
+		Kind   LinkKind
+		Text   string
+		Target string
+		Line   int
+	}
+	var got []mystSummary
+	for _, link := range doc.Links {
+		got = append(got, mystSummary{link.Kind, link.Text, link.Target, link.Line})
+	}
+	want := []mystSummary{
+		{LinkToctree, "", "install", 8},
+		{LinkToctree, "glob", "tutorials/*", 9},
+		{LinkToctree, "", "/reference/api", 17},
+		{LinkRole, "doc", "guide/setup", 20},
+		{LinkRole, "ref", "sec:intro", 20},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected myst links\nwant: %#v\n got: %#v", want, got)
+	}
+	if !reflect.DeepEqual(doc.Labels, []string{"intro-label"}) {
+		t.Fatalf("unexpected labels: %#v", doc.Labels)
+	}
+	if links := Parse(content).Links; len(links) != 0 {
+		t.Fatalf("expected myst syntax to be ignored outside myst mode, got %#v", links)
+	}
+}
+
+func TestParseWith_IncludeDirectives(t *testing.T) {
+	includes, err := ParseIncludePatterns([]string{"snippets", "markdown-include", "comment", `@import\s+(\S+)`})
+	if err != nil {
+		t.Fatalf("parse include patterns: %v", err)
+	}
+	content := "--8<-- \"snippets/intro.md\"\n\n" +
+		"Text {!shared/footer.md!} and --8<-- \"not-at-line-start.md\".\n\n" +
+		"<!-- include: partials/table.md -->\n\n" +
+		";--8<-- 'escaped.md'\n\n" +
+		"@import custom.md\n\n" +
+		"```python\n--8<-- \"examples/main.py\"\n```\n\n" +
+		"```\n{!in-code.md!}\n<!-- include: in-code-comment.md -->\n@import in-code-pattern.md\n```\n"
+
+	doc := ParseWith(content, Options{Includes: includes})
+	type includeSummary struct {
+		Name   string
+		Target string
+		Line   int
+	}
+	var got []includeSummary
+	for _, link := range doc.Links {
+		if link.Kind != LinkInclude {
+			t.Fatalf("unexpected link kind %q for %q", link.Kind, link.Destination)
+		}
+		got = append(got, includeSummary{link.Text, link.Target, link.Line})
+	}
+	want := []includeSummary{
+		{"snippets", "snippets/intro.md", 1},
+		{"markdown-include", "shared/footer.md", 3},
+		{"comment", "partials/table.md", 5},
+		{"pattern", "custom.md", 9},
+		{"snippets", "examples/main.py", 12},
+		{"markdown-include", "in-code.md", 16},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected includes\nwant: %#v\n got: %#v", want, got)
+	}
+
+	block := "```\n--8<--\nexamples/setup.sh\n\n; skipped.md\n  shared/footer.md:3:9\n--8<--\n```\n"
+	doc = ParseWith(block, Options{Includes: includes[:1]})
+	got = nil
+	for _, link := range doc.Links {
+		got = append(got, includeSummary{link.Text, link.Target, link.Line})
+	}
+	want = []includeSummary{
+		{"snippets", "examples/setup.sh", 3},
+		{"snippets", "shared/footer.md", 6},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected snippet block includes\nwant: %#v\n got: %#v", want, got)
+	}
+
+	if _, err := ParseIncludePatterns([]string{"no-group"}); err == nil {
+		t.Fatalf("expected error for pattern without capture group")
+	}
+}
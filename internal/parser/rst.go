@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	rstInlineLiteral = regexp.MustCompile("``.+?``")
+	rstRole          = regexp.MustCompile(":(doc|download):`([^`]+)`")
+	rstEmbeddedURI   = regexp.MustCompile("`(?:[^`<]*\\s)?<([^`<>]+)>`__?")
+	rstFileDirective = regexp.MustCompile(`^\s*\.\.\s+(?:\|[^|]+\|\s+)?(include|literalinclude|image|figure)::\s*(\S.*?)\s*$`)
+	rstTargetLine    = regexp.MustCompile(`^\s*\.\.\s+_([^:` + "`" + `]+):\s*(.*?)\s*$`)
+	rstDirectiveLine = regexp.MustCompile(`^\s*\.\.\s+(?:\|[^|]+\|\s+)?([\w:.+-]+)::`)
+)
+
+// rstLiteralDirectives hold code or raw output whose body is never scanned.
+var rstLiteralDirectives = map[string]struct{}{
+	"code": {}, "code-block": {}, "sourcecode": {}, "raw": {}, "parsed-literal": {}, "math": {},
+}
+
+// RSTExtractor reads reStructuredText: :doc: and :download: roles, embedded
+// URIs (`text <file.rst>`_), hyperlink targets, and include/image/figure
+// directives. Literal blocks, code directives and comments are skipped.
+type RSTExtractor struct{}
+
+func (RSTExtractor) Extract(content string) Document {
+	positions := newLineIndex(content)
+	lines := splitLines(content)
+	links := make([]Link, 0)
+	doc := Document{}
+	adornments := make([]string, 0)
+	skipIndent := -1
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		text := content[line.start:line.end]
+		indent := lineIndent(text)
+		if skipIndent >= 0 {
+			if strings.TrimSpace(text) == "" || indent > skipIndent {
+				continue
+			}
+			skipIndent = -1
+		}
+
+		if m := rstFileDirective.FindStringSubmatchIndex(text); m != nil {
+			kind := LinkInclude
+			if name := text[m[2]:m[3]]; name == "image" || name == "figure" {
+				kind = LinkImage
+			}
+			links = append(links, Link{Kind: kind, Destination: text[m[4]:m[5]], Offset: line.start + m[4]})
+			continue
+		}
+		if m := rstTargetLine.FindStringSubmatchIndex(text); m != nil {
+			if m[4] == m[5] {
+				doc.Anchors = append(doc.Anchors, rstID(text[m[2]:m[3]]))
+				doc.Labels = append(doc.Labels, text[m[2]:m[3]])
+			} else if uri := text[m[4]:m[5]]; !strings.HasSuffix(uri, "_") {
+				links = append(links, Link{Kind: LinkReference, Text: text[m[2]:m[3]], Destination: uri, Offset: line.start + m[4]})
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(text), "..") {
+			if m := rstDirectiveLine.FindStringSubmatch(text); m != nil {
+				if _, literal := rstLiteralDirectives[m[1]]; literal {
+					skipIndent = indent
+				}
+				continue
+			}
+			// Anything else starting with ".." is a comment, body included.
+			skipIndent = indent
+			continue
+		}
+
+		if i+1 < len(lines) {
+			if underline := content[lines[i+1].start:lines[i+1].end]; isRSTSectionTitle(text, underline) {
+				adornments, doc.Headings = appendRSTHeading(adornments, doc.Headings, text, underline, positions, line.start)
+				i++
+				continue
+			}
+		}
+
+		links = append(links, rstInlineLinks(text, line.start)...)
+		if strings.HasSuffix(strings.TrimRight(text, " \t"), "::") {
+			skipIndent = indent
+		}
+	}
+
+	doc.Links = resolveLinks(positions, links)
+	return doc
+}
+
+func rstInlineLinks(text string, offset int) []Link {
+	text = rstInlineLiteral.ReplaceAllStringFunc(text, func(m string) string { return strings.Repeat(" ", len(m)) })
+	links := make([]Link, 0)
+	for _, m := range rstRole.FindAllStringSubmatchIndex(text, -1) {
+		role, target, at := text[m[2]:m[3]], text[m[4]:m[5]], m[4]
+		if open, closeAt := strings.LastIndexByte(target, '<'), strings.LastIndexByte(target, '>'); open >= 0 && closeAt > open {
+			at += open + 1
+			target = target[open+1 : closeAt]
+		}
+		if role == "doc" && path.Ext(target) == "" {
+			target += ".rst"
+		}
+		links = append(links, Link{Kind: LinkRole, Text: role, Destination: target, Offset: offset + at})
+	}
+	for _, m := range rstEmbeddedURI.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > 0 && text[m[0]-1] == ':' {
+			continue
+		}
+		target := text[m[2]:m[3]]
+		if strings.HasSuffix(target, "_") {
+			continue
+		}
+		links = append(links, Link{Kind: LinkInline, Destination: target, Offset: offset + m[2]})
+	}
+	return links
+}
+
+func isRSTSectionTitle(text, underline string) bool {
+	if strings.TrimSpace(text) == "" || lineIndent(text) > 0 || isRSTAdornment(text) {
+		return false
+	}
+	return isRSTAdornment(underline) && utf8.RuneCountInString(strings.TrimRight(underline, " \t")) >= utf8.RuneCountInString(strings.TrimRight(text, " \t"))
+}
+
+func isRSTAdornment(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 2 || !isASCIIPunct(line[0]) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
+
+// appendRSTHeading assigns levels in order of first appearance of each
+// underline character, as docutils does.
+func appendRSTHeading(adornments []string, headings []Heading, text, underline string, positions lineIndex, offset int) ([]string, []Heading) {
+	level := 0
+	for i, seen := range adornments {
+		if seen == underline[:1] {
+			level = i + 1
+		}
+	}
+	if level == 0 {
+		adornments = append(adornments, underline[:1])
+		level = len(adornments)
+	}
+	title := strings.TrimSpace(text)
+	line, _ := positions.position(offset)
+	return adornments, append(headings, Heading{Text: title, ID: rstID(title), Level: level, Line: line})
+}
+
+// rstID mirrors docutils' make_id: lowercase, with runs of anything other
+// than letters and digits collapsed to single hyphens.
+func rstID(text string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingHyphen = true
+	}
+	return b.String()
+}
+
+type lineSpan struct {
+	start int
+	end   int
+}
+
+func splitLines(content string) []lineSpan {
+	lines := make([]lineSpan, 0)
+	for start := 0; start <= len(content); {
+		end := strings.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += start
+		}
+		stop := end
+		if stop > start && content[stop-1] == '\r' {
+			stop--
+		}
+		lines = append(lines, lineSpan{start: start, end: stop})
+		if end >= len(content) {
+			break
+		}
+		start = end + 1
+	}
+	return lines
+}
+
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
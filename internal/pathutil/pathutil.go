@@ -2,6 +2,9 @@ package pathutil
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -32,6 +35,24 @@ func NormalizeExtensions(raw string) []string {
 	return exts
 }
 
+func SplitList(raw string) []string {
+	parts := strings.Split(raw, ",")
+	seen := make(map[string]struct{}, len(parts))
+	items := make([]string, 0, len(parts))
+	for _, part := range parts {
+		item := strings.TrimSpace(part)
+		if item == "" {
+			continue
+		}
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		items = append(items, item)
+	}
+	return items
+}
+
 func ExtensionSet(extensions []string) map[string]struct{} {
 	set := make(map[string]struct{}, len(extensions))
 	for _, ext := range extensions {
@@ -60,6 +81,20 @@ func NormalizeAbs(path string) (string, error) {
 	return filepath.Clean(abs), nil
 }
 
+func FindRepoRoot(dir string) (string, bool) {
+	current := filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, true
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
+
 func IsWithinDir(dir, path string) bool {
 	rel, err := filepath.Rel(dir, path)
 	if err != nil {
@@ -90,3 +125,93 @@ func RelativeSlashMany(baseDir string, paths []string) ([]string, error) {
 	}
 	return out, nil
 }
+
+func MatchPattern(pattern, rel string) bool {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return false
+	}
+	target := filepath.ToSlash(rel)
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(target)
+	}
+	matched, err := path.Match(pattern, target)
+	return err == nil && matched
+}
+
+func MatchAnyPattern(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if MatchPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// FSPath converts an absolute path under dir into the slash-separated name
+// used by an fs.FS rooted at dir. It reports false for paths outside dir.
+func FSPath(dir, path string) (string, bool) {
+	if !IsWithinDir(dir, path) {
+		return "", false
+	}
+	rel, err := RelativeSlash(dir, path)
+	if err != nil || !fs.ValidPath(rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+// Canonical resolves symlinks in path so that every route to a file yields
+// one identity. Missing trailing elements are kept and joined onto their
+// nearest existing parent. When the real path lies outside dir, path is
+// returned unchanged so files linked in from elsewhere stay under dir; dir
+// itself must already be free of symlinks.
+func Canonical(dir, path string) string {
+	return CanonicalIn([]string{dir}, path)
+}
+
+// CanonicalIn is Canonical for several scan directories: the real path is
+// used when it lies inside any of dirs.
+func CanonicalIn(dirs []string, path string) string {
+	rest := ""
+	for current := path; ; {
+		if real, err := filepath.EvalSymlinks(current); err == nil {
+			real = filepath.Join(real, rest)
+			if _, ok := ContainingDir(dirs, real); !ok {
+				return path
+			}
+			return real
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
+
+// ContainingDir returns the deepest of dirs that holds path. It reports false
+// when path is outside all of them.
+func ContainingDir(dirs []string, path string) (string, bool) {
+	best, found := "", false
+	for _, dir := range dirs {
+		if IsWithinDir(dir, path) && (!found || len(dir) > len(best)) {
+			best, found = dir, true
+		}
+	}
+	return best, found
+}
+
+// RelativeSlashIn returns path relative to the deepest of dirs that holds it,
+// or to the first dir when none does.
+func RelativeSlashIn(dirs []string, path string) (string, error) {
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no base directory for %s", path)
+	}
+	dir, ok := ContainingDir(dirs, path)
+	if !ok {
+		dir = dirs[0]
+	}
+	return RelativeSlash(dir, path)
+}
@@ -1,6 +1,7 @@
 package pathutil
 
 import (
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -51,3 +52,93 @@ func TestIsWithinDir(t *testing.T) {
 		t.Fatal("expected outside path to not be within dir")
 	}
 }
+
+func TestFSPath(t *testing.T) {
+	base := filepath.FromSlash("/docs")
+	if got, ok := FSPath(base, filepath.Join(base, "guide", "a.md")); !ok || got != "guide/a.md" {
+		t.Fatalf("FSPath inside = %q, %v", got, ok)
+	}
+	if got, ok := FSPath(base, base); !ok || got != "." {
+		t.Fatalf("FSPath dir = %q, %v", got, ok)
+	}
+	if _, ok := FSPath(base, filepath.FromSlash("/other/a.md")); ok {
+		t.Fatal("expected outside path to be rejected")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("eval temp dir: %v", err)
+	}
+	dir := filepath.Join(base, "docs")
+	real := filepath.Join(dir, "real")
+	outside := filepath.Join(base, "outside")
+	for _, d := range []string{real, outside} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+	}
+	if err := os.Symlink(real, filepath.Join(dir, "alias")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "ext")); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+
+	if got := Canonical(dir, filepath.Join(dir, "alias", "page.md")); got != filepath.Join(real, "page.md") {
+		t.Fatalf("Canonical through alias = %q", got)
+	}
+	if got := Canonical(dir, filepath.Join(dir, "alias", "missing", "page")); got != filepath.Join(real, "missing", "page") {
+		t.Fatalf("Canonical of missing path = %q", got)
+	}
+	if want := filepath.Join(dir, "ext", "page.md"); Canonical(dir, want) != want {
+		t.Fatalf("Canonical should keep paths whose real location is outside dir")
+	}
+}
+
+func TestRelativeSlashIn(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+	docs := filepath.Join(base, "docs")
+	api := filepath.Join(docs, "api")
+	handbook := filepath.Join(base, "handbook")
+	dirs := []string{docs, handbook, api}
+
+	cases := map[string]string{
+		filepath.Join(docs, "guide", "intro.md"): "guide/intro.md",
+		filepath.Join(api, "ref.md"):             "ref.md",
+		filepath.Join(handbook, "onboard.md"):    "onboard.md",
+		filepath.Join(base, "README.md"):         "../README.md",
+	}
+	for path, want := range cases {
+		got, err := RelativeSlashIn(dirs, path)
+		if err != nil {
+			t.Fatalf("RelativeSlashIn(%q) failed: %v", path, err)
+		}
+		if got != want {
+			t.Fatalf("RelativeSlashIn(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if _, ok := ContainingDir(dirs, filepath.Join(base, "README.md")); ok {
+		t.Fatalf("ContainingDir should report paths outside every dir")
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*.png", "img/logo.png", true},
+		{"*.png", "logo.svg", false},
+		{"img/*.svg", "img/logo.svg", true},
+		{"img/*.svg", "other/img/logo.svg", false},
+		{"", "logo.png", false},
+	}
+	for _, tc := range cases {
+		if got := MatchPattern(tc.pattern, tc.rel); got != tc.want {
+			t.Fatalf("MatchPattern(%q, %q) = %v, want %v", tc.pattern, tc.rel, got, tc.want)
+		}
+	}
+}
@@ -7,18 +7,39 @@ import (
 )
 
 type Summary struct {
-	Scanned   int `json:"scanned"`
-	Reachable int `json:"reachable"`
-	Orphans   int `json:"orphans"`
+	Scanned      int `json:"scanned"`
+	Reachable    int `json:"reachable"`
+	Orphans      int `json:"orphans"`
+	Assets       int `json:"assets,omitempty"`
+	OrphanAssets int `json:"orphanAssets,omitempty"`
 }
 
+type Warning struct {
+	Kind        string   `json:"kind"`
+	File        string   `json:"file"`
+	Line        int      `json:"line"`
+	Column      int      `json:"column"`
+	Destination string   `json:"destination"`
+	Target      string   `json:"target,omitempty"`
+	Candidates  []string `json:"candidates,omitempty"`
+	Message     string   `json:"message"`
+}
+
+// Result is the rendered outcome of a check. Root and Dir are the first root
+// and scan directory; Roots and Dirs list all of them when there are several.
+// WarningMessages keeps the plain-string warnings of earlier JSON output;
+// RenderJSON fills it from Warnings.
 type Result struct {
-	Root     string   `json:"root"`
-	Dir      string   `json:"dir"`
-	Orphans  []string `json:"orphans"`
-	Warnings []string `json:"warnings,omitempty"`
-	Graph    string   `json:"graph,omitempty"`
-	Summary  Summary  `json:"summary"`
+	Root            string    `json:"root"`
+	Roots           []string  `json:"roots,omitempty"`
+	Dir             string    `json:"dir"`
+	Dirs            []string  `json:"dirs,omitempty"`
+	Orphans         []string  `json:"orphans"`
+	OrphanAssets    []string  `json:"orphanAssets,omitempty"`
+	Warnings        []Warning `json:"warnings,omitempty"`
+	WarningMessages []string  `json:"warningMessages,omitempty"`
+	Graph           string    `json:"graph,omitempty"`
+	Summary         Summary   `json:"summary"`
 }
 
 func RenderText(r Result, verbose bool, showWarnings bool, showGraph bool) string {
@@ -32,14 +53,26 @@ func RenderText(r Result, verbose bool, showWarnings bool, showGraph bool) strin
 		}
 	}
 
+	if len(r.OrphanAssets) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("Orphan assets (%d):", len(r.OrphanAssets)))
+		for _, asset := range r.OrphanAssets {
+			lines = append(lines, "- "+asset)
+		}
+	}
+
 	if verbose {
 		lines = append(lines, "")
 		lines = append(lines, "Summary:")
-		lines = append(lines, fmt.Sprintf("- root: %s", r.Root))
-		lines = append(lines, fmt.Sprintf("- dir: %s", r.Dir))
+		lines = append(lines, fmt.Sprintf("- root: %s", joinOr(r.Roots, r.Root)))
+		lines = append(lines, fmt.Sprintf("- dir: %s", joinOr(r.Dirs, r.Dir)))
 		lines = append(lines, fmt.Sprintf("- scanned: %d", r.Summary.Scanned))
 		lines = append(lines, fmt.Sprintf("- reachable: %d", r.Summary.Reachable))
 		lines = append(lines, fmt.Sprintf("- orphans: %d", r.Summary.Orphans))
+		if r.Summary.Assets > 0 {
+			lines = append(lines, fmt.Sprintf("- assets: %d", r.Summary.Assets))
+			lines = append(lines, fmt.Sprintf("- orphan assets: %d", r.Summary.OrphanAssets))
+		}
 		lines = append(lines, fmt.Sprintf("- warnings: %d", len(r.Warnings)))
 	}
 
@@ -47,7 +80,7 @@ func RenderText(r Result, verbose bool, showWarnings bool, showGraph bool) strin
 		lines = append(lines, "")
 		lines = append(lines, fmt.Sprintf("Unresolved local links (%d):", len(r.Warnings)))
 		for _, warning := range r.Warnings {
-			lines = append(lines, "- "+warning)
+			lines = append(lines, "- "+warning.Message)
 		}
 	}
 
@@ -60,7 +93,18 @@ func RenderText(r Result, verbose bool, showWarnings bool, showGraph bool) strin
 	return strings.Join(lines, "\n")
 }
 
+func joinOr(paths []string, single string) string {
+	if len(paths) == 0 {
+		return single
+	}
+	return strings.Join(paths, ", ")
+}
+
 func RenderJSON(r Result) (string, error) {
+	r.WarningMessages = nil
+	for _, warning := range r.Warnings {
+		r.WarningMessages = append(r.WarningMessages, warning.Message)
+	}
 	out, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("marshal report json: %w", err)
@@ -42,7 +42,7 @@ func TestRenderText_WithOrphans(t *testing.T) {
 
 func TestRenderText_WithWarningsAndGraph(t *testing.T) {
 	r := Result{
-		Warnings: []string{"unresolved local markdown link: a -> b"},
+		Warnings: []Warning{{Kind: "unresolved-link", File: "a.md", Line: 3, Column: 7, Destination: "./b.md", Message: "unresolved local markdown link: a.md:3:7 -> ./b.md"}},
 		Graph:    "graph TD\n  \"a\" --> \"b\"",
 	}
 
@@ -50,6 +50,9 @@ func TestRenderText_WithWarningsAndGraph(t *testing.T) {
 	if !strings.Contains(out, "Unresolved local links (1):") {
 		t.Fatalf("expected warnings section, got: %s", out)
 	}
+	if !strings.Contains(out, "- unresolved local markdown link: a.md:3:7 -> ./b.md") {
+		t.Fatalf("expected warning location, got: %s", out)
+	}
 	if !strings.Contains(out, "Graph:") || !strings.Contains(out, `"a" --> "b"`) {
 		t.Fatalf("expected graph section, got: %s", out)
 	}
@@ -74,3 +77,22 @@ func TestRenderJSON(t *testing.T) {
 		t.Fatalf("expected summary in json output, got: %s", out)
 	}
 }
+
+func TestRenderJSON_StructuredWarnings(t *testing.T) {
+	r := Result{
+		Warnings: []Warning{{Kind: "unresolved-link", File: "index.md", Line: 42, Column: 7, Destination: "./missing.md", Target: "missing.md", Message: "unresolved local markdown link: index.md:42:7 -> ./missing.md"}},
+	}
+
+	out, err := RenderJSON(r)
+	if err != nil {
+		t.Fatalf("render json failed: %v", err)
+	}
+	for _, want := range []string{`"kind": "unresolved-link"`, `"file": "index.md"`, `"line": 42`, `"column": 7`, `"destination": "./missing.md"`, `"target": "missing.md"`, `"warningMessages": [`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in json output, got: %s", want, out)
+		}
+	}
+	if n := strings.Count(out, `"unresolved local markdown link: index.md:42:7 -\u003e ./missing.md"`); n != 2 {
+		t.Fatalf("expected the message in warnings and warningMessages, found %d times: %s", n, out)
+	}
+}
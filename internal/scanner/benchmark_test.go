@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,7 +19,7 @@ func BenchmarkScanLargeTree(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		files, err := Scan(opts)
+		files, err := Scan(context.Background(), opts)
 		if err != nil {
 			b.Fatalf("scan failed: %v", err)
 		}
@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// gitFiles lists the files in git's index under dir, as slash paths relative
+// to dir. With untracked set it also lists untracked files that the repo's
+// exclude rules do not ignore.
+func gitFiles(ctx context.Context, dir string, untracked bool) ([]string, error) {
+	args := []string{"-C", dir, "ls-files", "-z", "--cached"}
+	if untracked {
+		args = append(args, "--others", "--exclude-standard")
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("list git files: %s", msg)
+		}
+		return nil, fmt.Errorf("list git files: %w", err)
+	}
+
+	seen := make(map[string]struct{})
+	names := make([]string, 0)
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" {
+			continue
+		}
+		// Unmerged paths are listed once per stage.
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// trackedWalk feeds git's file list through a walk function as fs.WalkDir
+// would: the root and every parent directory are visited before the files in
+// them, so ignore files load in order and an ignored directory drops its
+// files.
+type trackedWalk struct {
+	fsys fs.FS
+	walk fs.WalkDirFunc
+	dirs map[string]bool
+}
+
+func walkTracked(ctx context.Context, fsys fs.FS, dir string, untracked bool, walk fs.WalkDirFunc) error {
+	names, err := gitFiles(ctx, dir, untracked)
+	if err != nil {
+		return err
+	}
+	t := &trackedWalk{fsys: fsys, walk: walk, dirs: make(map[string]bool)}
+	if _, err := t.enter("."); err != nil {
+		return err
+	}
+	for _, name := range names {
+		ok, err := t.enter(path.Dir(name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		info, err := fs.Stat(fsys, name)
+		// Files deleted from the work tree, submodules and symlinks to
+		// directories have nothing to parse.
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if err := walk(name, fs.FileInfoToDirEntry(info), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enter visits dir and its parents once each and reports whether files under
+// dir are still part of the scan.
+func (t *trackedWalk) enter(dir string) (bool, error) {
+	if ok, seen := t.dirs[dir]; seen {
+		return ok, nil
+	}
+	if dir != "." {
+		ok, err := t.enter(path.Dir(dir))
+		if err != nil || !ok {
+			t.dirs[dir] = false
+			return false, err
+		}
+	}
+	info, err := fs.Stat(t.fsys, dir)
+	if err != nil {
+		t.dirs[dir] = false
+		return false, nil
+	}
+	err = t.walk(dir, fs.FileInfoToDirEntry(info), nil)
+	if err == fs.SkipDir {
+		t.dirs[dir] = false
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	t.dirs[dir] = true
+	return true, nil
+}
@@ -0,0 +1,382 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+)
+
+// ignoreFileName is the per-directory ignore file read on every scan.
+const ignoreFileName = ".gorphanignore"
+
+// ignorePattern is one line of a gitignore-style file. Patterns without
+// wildcards or escapes keep re nil and compare literal directly, which keeps
+// plain path rules as cheap as string comparisons.
+type ignorePattern struct {
+	re       *regexp.Regexp
+	literal  string
+	anchored bool
+	negate   bool
+	dirOnly  bool
+}
+
+// ignoreTree decides which paths a scan skips: those an --ignore rule or an
+// ignore file excludes, unless a "!" rule in either re-includes them. Ignore
+// files seen during the walk are checked deepest first. Ignore file
+// paths are slash-separated and relative to top, the repository root when
+// gitignore handling is on and a repository encloses the scan dir, so
+// .gitignore files above the scan dir apply too. lead is the scan dir
+// relative to top.
+type ignoreTree struct {
+	rules ignoreMatcher
+	git   bool
+	lead  string
+	byDir map[string][]ignorePattern
+	// fallback holds .git/info/exclude and the global excludes file, lowest
+	// precedence last.
+	fallback [][]ignorePattern
+}
+
+// parseIgnorePatterns compiles content in gitignore syntax: "#" comments,
+// "!" negation, a trailing "/" for directories only, anchoring for patterns
+// containing "/", and "**" for any number of directories.
+func parseIgnorePatterns(content string) []ignorePattern {
+	patterns := make([]ignorePattern, 0)
+	lines := bufio.NewScanner(strings.NewReader(content))
+	for lines.Scan() {
+		if p, ok := compileIgnorePattern(lines.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+func compileIgnorePattern(line string) (ignorePattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+	trimmed := strings.TrimRight(line, " \t")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+		trimmed += " "
+	}
+	line = trimmed
+
+	p := ignorePattern{}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	p.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if !strings.ContainsAny(line, `*?[\`) {
+		p.literal = line
+		return p, true
+	}
+	re, err := ignoreRegexp(line, p.anchored)
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+func (p ignorePattern) matches(rel string) bool {
+	switch {
+	case p.re != nil:
+		return p.re.MatchString(rel)
+	case p.anchored:
+		return rel == p.literal
+	default:
+		return rel == p.literal || strings.HasSuffix(rel, "/"+p.literal)
+	}
+}
+
+// ignoreRegexp translates a gitignore glob. Unanchored patterns match at any
+// depth; "*" and "?" never cross "/".
+func ignoreRegexp(pattern string, anchored bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		atSegment := i == 0 || pattern[i-1] == '/'
+		switch {
+		case atSegment && strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case atSegment && pattern[i:] == "**":
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func (t *ignoreTree) add(dir string, patterns []ignorePattern) {
+	if len(patterns) == 0 {
+		return
+	}
+	if t.byDir == nil {
+		t.byDir = make(map[string][]ignorePattern)
+	}
+	t.byDir[dir] = append(t.byDir[dir], patterns...)
+}
+
+// loadDir reads the ignore files of the scan-dir relative directory dir.
+// A .gorphanignore overrides a .gitignore in the same directory.
+func (t *ignoreTree) loadDir(fsys fs.FS, dir string) error {
+	if t.git {
+		if err := t.load(fsys, dir, ".gitignore"); err != nil {
+			return err
+		}
+	}
+	return t.load(fsys, dir, ignoreFileName)
+}
+
+// load reads name in the scan-dir relative directory dir, if present.
+func (t *ignoreTree) load(fsys fs.FS, dir, name string) error {
+	b, err := fs.ReadFile(fsys, path.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path.Join(dir, name), err)
+	}
+	t.add(t.topPath(dir), parseIgnorePatterns(string(b)))
+	return nil
+}
+
+func (t *ignoreTree) topPath(rel string) string {
+	if t.lead == "" {
+		return rel
+	}
+	return path.Join(t.lead, rel)
+}
+
+// ignored reports whether the scan-dir relative path rel is excluded. The
+// last matching --ignore rule and the last matching line of the deepest
+// matching ignore file each decide for their source; a negation from either
+// source re-includes the path.
+func (t *ignoreTree) ignored(rel string, isDir bool) bool {
+	matched, ignored := t.rules.match(rel, isDir)
+	if matched && !ignored {
+		return false
+	}
+	if fileMatched, fileIgnored := t.matchFiles(rel, isDir); fileMatched {
+		return fileIgnored
+	}
+	return ignored
+}
+
+// matchFiles matches rel against the ignore files, deepest first, then the
+// fallback excludes files.
+func (t *ignoreTree) matchFiles(rel string, isDir bool) (matched, ignored bool) {
+	full := t.topPath(rel)
+	dir := path.Dir(full)
+	for {
+		if patterns, ok := t.byDir[dir]; ok {
+			sub := full
+			if dir != "." {
+				sub = strings.TrimPrefix(full, dir+"/")
+			}
+			if matched, ignored := matchIgnorePatterns(patterns, sub, isDir); matched {
+				return true, ignored
+			}
+		}
+		if dir == "." {
+			break
+		}
+		dir = path.Dir(dir)
+	}
+	for _, patterns := range t.fallback {
+		if matched, ignored := matchIgnorePatterns(patterns, full, isDir); matched {
+			return true, ignored
+		}
+	}
+	return false, false
+}
+
+// skips reports whether the walk should leave out the entry at the
+// scan-dir relative path rel.
+func (t *ignoreTree) skips(rel string, d fs.DirEntry) bool {
+	if t.git && d.IsDir() && d.Name() == ".git" {
+		return true
+	}
+	return t.ignored(rel, d.IsDir())
+}
+
+func matchIgnorePatterns(patterns []ignorePattern, rel string, isDir bool) (matched, ignored bool) {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		p := patterns[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.matches(rel) {
+			return true, !p.negate
+		}
+	}
+	return false, false
+}
+
+// newIgnoreTree prepares the ignore rules for a scan of absDir. With
+// GitIgnore on the OS filesystem it also loads the global excludes file,
+// .git/info/exclude and the .gitignore files between the repository root
+// and absDir; the walk loads the ignore files inside absDir.
+func newIgnoreTree(absDir string, opts Options) (*ignoreTree, error) {
+	t := &ignoreTree{rules: compileIgnoreRules(opts.Ignore), git: opts.GitIgnore}
+	if !opts.GitIgnore || opts.FS != nil {
+		return t, nil
+	}
+	repoRoot, inRepo := pathutil.FindRepoRoot(absDir)
+	if inRepo {
+		lead, err := pathutil.RelativeSlash(repoRoot, absDir)
+		if err != nil {
+			return nil, fmt.Errorf("resolve repository path: %w", err)
+		}
+		if lead != "." {
+			t.lead = lead
+		}
+		gitDir := resolveGitDir(repoRoot)
+		t.fallback = append(t.fallback, readIgnoreFile(filepath.Join(gitDir, "info", "exclude")))
+		for dir := t.lead; dir != "."; {
+			dir = path.Dir(dir)
+			t.add(dir, readIgnoreFile(filepath.Join(repoRoot, filepath.FromSlash(dir), ".gitignore")))
+		}
+	}
+	if excludes := globalExcludesFile(); excludes != "" {
+		t.fallback = append(t.fallback, readIgnoreFile(excludes))
+	}
+	return t, nil
+}
+
+func readIgnoreFile(name string) []ignorePattern {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+	return parseIgnorePatterns(string(b))
+}
+
+// resolveGitDir follows a "gitdir:" file, as used by worktrees and
+// submodules, to the real git directory.
+func resolveGitDir(repoRoot string) string {
+	gitPath := filepath.Join(repoRoot, ".git")
+	info, err := os.Stat(gitPath)
+	if err != nil || info.IsDir() {
+		return gitPath
+	}
+	b, err := os.ReadFile(gitPath)
+	if err != nil {
+		return gitPath
+	}
+	dir, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir:")
+	if !ok {
+		return gitPath
+	}
+	dir = filepath.FromSlash(strings.TrimSpace(dir))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoRoot, dir)
+	}
+	return dir
+}
+
+// globalExcludesFile returns core.excludesFile from the user's git config,
+// or git's default $XDG_CONFIG_HOME/git/ignore.
+func globalExcludesFile() string {
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	configs := make([]string, 0, 2)
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+	if xdg != "" {
+		configs = append(configs, filepath.Join(xdg, "git", "config"))
+	}
+	for _, config := range configs {
+		if value := gitConfigValue(config, "core", "excludesfile"); value != "" {
+			if rest, ok := strings.CutPrefix(value, "~/"); ok && home != "" {
+				return filepath.Join(home, filepath.FromSlash(rest))
+			}
+			return value
+		}
+	}
+	if xdg == "" {
+		return ""
+	}
+	return filepath.Join(xdg, "git", "ignore")
+}
+
+// gitConfigValue reads one key from a git config file. It understands plain
+// "[section]" headers and "key = value" lines, which covers excludesFile.
+func gitConfigValue(name, section, key string) string {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+	current := ""
+	lines := bufio.NewScanner(strings.NewReader(string(b)))
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || current != section || !strings.EqualFold(strings.TrimSpace(k), key) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	return ""
+}
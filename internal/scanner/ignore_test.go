@@ -0,0 +1,250 @@
+package scanner
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
+)
+
+func TestIgnorePatterns_GitignoreSemantics(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.md", "a.md", false, true},
+		{"*.md", "deep/nested/a.md", false, true},
+		{"/build", "build", true, true},
+		{"/build", "sub/build", true, false},
+		{"docs/gen", "docs/gen", true, true},
+		{"docs/gen", "x/docs/gen", true, false},
+		{"out/", "out", true, true},
+		{"out/", "out", false, false},
+		{"out/", "sub/out", true, true},
+		{"**/tmp", "a/b/tmp", true, true},
+		{"**/tmp", "tmp", true, true},
+		{"a/**/b.md", "a/b.md", false, true},
+		{"a/**/b.md", "a/x/y/b.md", false, true},
+		{"vendor/**", "vendor/pkg/readme.md", false, true},
+		{"vendor/**", "vendor", true, false},
+		{"?.md", "a.md", false, true},
+		{"?.md", "ab.md", false, false},
+		{"[!a]*.md", "b.md", false, true},
+		{"[!a]*.md", "a.md", false, false},
+		{`\#notes.md`, "#notes.md", false, true},
+		{"# comment", "# comment", false, false},
+		{`\!important.md`, "!important.md", false, true},
+		{"trailing.md   ", "trailing.md", false, true},
+	}
+	for _, tt := range tests {
+		patterns := parseIgnorePatterns(tt.pattern)
+		matched, ignored := matchIgnorePatterns(patterns, tt.path, tt.isDir)
+		if got := matched && ignored; got != tt.want {
+			t.Errorf("pattern %q on %q (dir=%t): got %t, want %t", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestScanInventory_GitIgnoreFS(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		".gitignore":                    {Data: []byte("node_modules/\n*.gen.md\n!keep.gen.md\n/build\n")},
+		"index.md":                      {Data: []byte("# index")},
+		"api.gen.md":                    {Data: []byte("# generated")},
+		"keep.gen.md":                   {Data: []byte("# kept")},
+		"build/out.md":                  {Data: []byte("# build")},
+		"guide/build/notes.md":          {Data: []byte("# nested build is not anchored")},
+		"guide/.gitignore":              {Data: []byte("draft-*.md\n!draft-ok.md\n")},
+		"guide/draft-1.md":              {Data: []byte("# draft")},
+		"guide/draft-ok.md":             {Data: []byte("# ok")},
+		"node_modules/pkg/README.md":    {Data: []byte("# vendored")},
+		".git/info/readme.md":           {Data: []byte("# git internals")},
+		"other/.gitignore":              {Data: []byte("*\n")},
+		"other/hidden.md":               {Data: []byte("# hidden")},
+		"unrelated/guide/draft-2.md":    {Data: []byte("# guide rules do not apply here")},
+		"unrelated/node_modules/a.md":   {Data: []byte("# vendored")},
+		"unrelated/node_modules.md":     {Data: []byte("# file, not a directory")},
+		"unrelated/nested/other/ok.md":  {Data: []byte("# other rules do not apply here")},
+		"unrelated/nested/keep.gen.md":  {Data: []byte("# kept")},
+		"unrelated/nested/drop.gen.md":  {Data: []byte("# generated")},
+		"unrelated/nested/build/out.md": {Data: []byte("# not anchored to root")},
+	}
+
+	files, err := Scan(context.Background(), Options{Dir: dir, FS: fsys, Extensions: []string{".md"}, GitIgnore: true})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "guide", "build", "notes.md"),
+		filepath.Join(dir, "guide", "draft-ok.md"),
+		filepath.Join(dir, "index.md"),
+		filepath.Join(dir, "keep.gen.md"),
+		filepath.Join(dir, "unrelated", "guide", "draft-2.md"),
+		filepath.Join(dir, "unrelated", "nested", "build", "out.md"),
+		filepath.Join(dir, "unrelated", "nested", "keep.gen.md"),
+		filepath.Join(dir, "unrelated", "nested", "other", "ok.md"),
+		filepath.Join(dir, "unrelated", "node_modules.md"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", want, files)
+	}
+}
+
+func TestScanInventory_GitIgnoreOutsideScanDir(t *testing.T) {
+	repo := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	testutil.MustWrite(t, filepath.Join(home, ".gitconfig"), "[core]\n\texcludesFile = ~/global-ignore\n")
+	testutil.MustWrite(t, filepath.Join(home, "global-ignore"), "*.swp.md\n")
+	testutil.MustWrite(t, filepath.Join(repo, ".git", "info", "exclude"), "local/\n")
+	testutil.MustWrite(t, filepath.Join(repo, ".gitignore"), "generated/\n")
+	testutil.MustWrite(t, filepath.Join(repo, "docs", ".gitignore"), "!/generated/\n")
+	docs := filepath.Join(repo, "docs")
+	testutil.MustWrite(t, filepath.Join(docs, "index.md"), "# index")
+	testutil.MustWrite(t, filepath.Join(docs, "edit.swp.md"), "# editor swap")
+	testutil.MustWrite(t, filepath.Join(docs, "local", "scratch.md"), "# local")
+	testutil.MustWrite(t, filepath.Join(docs, "generated", "api.md"), "# re-included by docs/.gitignore")
+	testutil.MustWrite(t, filepath.Join(docs, "sub", "generated", "x.md"), "# ignored by the root .gitignore")
+	testutil.MustWrite(t, filepath.Join(docs, "sub", ".gitignore"), "")
+
+	files, err := Scan(context.Background(), Options{Dir: docs, Extensions: []string{".md"}, GitIgnore: true})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(docs, "generated", "api.md"),
+		filepath.Join(docs, "index.md"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", want, files)
+	}
+}
+
+func TestCompileIgnoreRules_LiteralFastPath(t *testing.T) {
+	matcher := compileIgnoreRules([]string{"drafts", "./guide/skip.md", "**/*.tmp.md", " "})
+	if len(matcher.patterns) != 3 {
+		t.Fatalf("expected 3 patterns, got %d", len(matcher.patterns))
+	}
+	if p := matcher.patterns[0]; p.re != nil || p.literal != "drafts" || !p.anchored {
+		t.Fatalf("expected anchored literal for drafts, got %+v", p)
+	}
+	if p := matcher.patterns[1]; p.re != nil || p.literal != "guide/skip.md" || !p.anchored {
+		t.Fatalf("expected anchored literal for guide/skip.md, got %+v", p)
+	}
+	if matcher.patterns[2].re == nil {
+		t.Fatal("expected doublestar rule to compile to a regexp")
+	}
+}
+
+func TestScan_IgnoreRulesGitignoreSyntax(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		"index.md":               {Data: []byte("# index")},
+		"archive/old.md":         {Data: []byte("# old")},
+		"archive/keep.md":        {Data: []byte("# keep")},
+		"deep/a/b/scratch.md":    {Data: []byte("# scratch")},
+		"deep/tmp":               {Data: []byte("tmp is a file here")},
+		"deep/tmp.md":            {Data: []byte("# not a directory")},
+		"nested/archive/note.md": {Data: []byte("# anchored rule does not apply")},
+		"tmp/x.md":               {Data: []byte("# directory-only rule")},
+	}
+
+	files, err := Scan(context.Background(), Options{
+		Dir:        dir,
+		FS:         fsys,
+		Extensions: []string{".md"},
+		Ignore:     []string{"/archive/*", "!/archive/keep.md", "**/scratch.md", "tmp/"},
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "archive", "keep.md"),
+		filepath.Join(dir, "deep", "tmp.md"),
+		filepath.Join(dir, "index.md"),
+		filepath.Join(dir, "nested", "archive", "note.md"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", want, files)
+	}
+}
+
+func TestScan_GorphanIgnoreFiles(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		".gitignore":              {Data: []byte("generated/\n")},
+		".gorphanignore":          {Data: []byte("**/partials/\n!generated/\n")},
+		"index.md":                {Data: []byte("# index")},
+		"generated/api.md":        {Data: []byte("# generated but checked")},
+		"guide/partials/intro.md": {Data: []byte("# partial")},
+		"guide/.gorphanignore":    {Data: []byte("*.draft.md\n")},
+		"guide/page.draft.md":     {Data: []byte("# draft")},
+		"guide/page.md":           {Data: []byte("# page")},
+		"other/page.draft.md":     {Data: []byte("# guide rules do not apply")},
+		"other/keep.md":           {Data: []byte("# keep")},
+	}
+
+	files, err := Scan(context.Background(), Options{
+		Dir:        dir,
+		FS:         fsys,
+		Extensions: []string{".md"},
+		Ignore:     []string{"!other/keep.md", "other/*.md"},
+		GitIgnore:  true,
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "generated", "api.md"),
+		filepath.Join(dir, "guide", "page.md"),
+		filepath.Join(dir, "index.md"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", want, files)
+	}
+}
+
+func TestScan_IgnoreRulesAndIgnoreFilesNegateEachOther(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		".gorphanignore":      {Data: []byte("!archive/keep.md\n*.wip.md\n")},
+		"index.md":            {Data: []byte("# index")},
+		"archive/old.md":      {Data: []byte("# old")},
+		"archive/keep.md":     {Data: []byte("# re-included by .gorphanignore")},
+		"drafts/a.md":         {Data: []byte("# top-level drafts")},
+		"guide/drafts/b.md":   {Data: []byte("# literal rules stay prefixes")},
+		"guide/page.wip.md":   {Data: []byte("# wip")},
+		"guide/keep.wip.md":   {Data: []byte("# re-included by --ignore")},
+		"guide/partials/p.md": {Data: []byte("# glob rules match at any depth")},
+	}
+
+	files, err := Scan(context.Background(), Options{
+		Dir:        dir,
+		FS:         fsys,
+		Extensions: []string{".md"},
+		Ignore:     []string{"archive/*", "drafts", "**/partials/", "!guide/keep.wip.md"},
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "archive", "keep.md"),
+		filepath.Join(dir, "guide", "drafts", "b.md"),
+		filepath.Join(dir, "guide", "keep.wip.md"),
+		filepath.Join(dir, "index.md"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", want, files)
+	}
+}
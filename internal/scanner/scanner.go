@@ -1,129 +1,197 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
-	"gorphan/internal/pathutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
 )
 
+// Options configures a scan. Returned paths are Dir joined with the path
+// inside FS, so an in-memory tree can use any absolute Dir.
 type Options struct {
-	Dir        string
+	// Dir is the directory to walk.
+	Dir string
+	// FS is the tree to walk, rooted at Dir. Nil walks the OS filesystem.
+	FS fs.FS
+	// Extensions select the page files, matched case-insensitively.
 	Extensions []string
-	Ignore     []string
+	// Ignore rules use gitignore syntax, as do .gorphanignore files, except
+	// that rules without glob characters are prefixes of paths under Dir.
+	Ignore []string
+	// Assets are globs for non-page files to collect, matched against the
+	// basename or the path relative to Dir.
+	Assets []string
+	// GitIgnore skips paths excluded by .gitignore files, .git/info/exclude
+	// and the global excludes file, and always skips .git directories.
+	GitIgnore bool
+	// GitTracked takes candidate files from git's index instead of walking
+	// Dir. Ignore rules and extension filtering still apply.
+	GitTracked bool
+	// GitUntracked adds untracked files git does not ignore and implies
+	// GitTracked. Both need the OS filesystem.
+	GitUntracked bool
+	// FollowSymlinks descends into symlinked directories on the OS
+	// filesystem, entering each real directory once, and reports files under
+	// their symlink-free path when that lies inside Dir.
+	FollowSymlinks bool
+	// Progress, when set, is called with the running count of collected
+	// files and assets.
+	Progress func(scanned int)
 }
 
+type Inventory struct {
+	Files  []string
+	Assets []string
+}
+
+// ignoreMatcher holds the --ignore rules, compiled once per scan.
 type ignoreMatcher struct {
-	globs    []string
-	prefixes []string
+	patterns []ignorePattern
 }
 
 func NormalizeExtensions(raw string) []string {
 	return pathutil.NormalizeExtensions(raw)
 }
 
-func Scan(opts Options) ([]string, error) {
+func Scan(ctx context.Context, opts Options) ([]string, error) {
+	inventory, err := ScanInventory(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return inventory.Files, nil
+}
+
+// ScanInventory walks the tree and collects markdown files and assets. The
+// walk stops with ctx's error once ctx is done.
+func ScanInventory(ctx context.Context, opts Options) (Inventory, error) {
 	if strings.TrimSpace(opts.Dir) == "" {
-		return nil, fmt.Errorf("scan dir is required")
+		return Inventory{}, fmt.Errorf("scan dir is required")
+	}
+	if (opts.GitTracked || opts.GitUntracked) && opts.FS != nil {
+		return Inventory{}, fmt.Errorf("git-tracked scan requires the OS filesystem")
 	}
 
 	absDir, err := filepath.Abs(opts.Dir)
 	if err != nil {
-		return nil, fmt.Errorf("resolve scan dir: %w", err)
+		return Inventory{}, fmt.Errorf("resolve scan dir: %w", err)
 	}
 	absDir = filepath.Clean(absDir)
+	var links *linkWalker
+	if opts.FollowSymlinks && opts.FS == nil {
+		if absDir, err = filepath.EvalSymlinks(absDir); err != nil {
+			return Inventory{}, fmt.Errorf("resolve scan dir: %w", err)
+		}
+		links = newLinkWalker(absDir)
+	}
+	fsys := opts.FS
+	if fsys == nil {
+		fsys = os.DirFS(absDir)
+	}
 
 	extSet := pathutil.ExtensionSet(opts.Extensions)
-	ignore := compileIgnoreRules(opts.Ignore)
+	ignore, err := newIgnoreTree(absDir, opts)
+	if err != nil {
+		return Inventory{}, err
+	}
 
 	files := make([]string, 0)
-	err = filepath.WalkDir(absDir, func(path string, d fs.DirEntry, walkErr error) error {
+	assets := make([]string, 0)
+	var walk fs.WalkDirFunc
+	walk = func(name string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
-
-		rel, err := filepath.Rel(absDir, path)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		rel = filepath.Clean(rel)
+		if links.linkedDir(fsys, name, d) {
+			// Walking the link itself reports it as a directory.
+			return fs.WalkDir(fsys, name, walk)
+		}
 
-		if rel != "." && ignore.matches(rel) {
+		rel := filepath.FromSlash(name)
+		path := filepath.Join(absDir, rel)
+		if rel != "." && ignore.skips(name, d) {
 			if d.IsDir() {
-				return filepath.SkipDir
+				return fs.SkipDir
 			}
 			return nil
 		}
 
 		if d.IsDir() {
-			return nil
+			if !links.enter(path) {
+				return fs.SkipDir
+			}
+			return ignore.loadDir(fsys, name)
 		}
 		ext := strings.ToLower(filepath.Ext(path))
-		if _, ok := extSet[ext]; !ok {
+		isFile := hasExt(extSet, ext)
+		if !isFile && (len(opts.Assets) == 0 || !pathutil.MatchAnyPattern(opts.Assets, rel)) {
 			return nil
 		}
-
-		files = append(files, filepath.Clean(path))
+		path, ok := links.collect(filepath.Clean(path))
+		if !ok {
+			return nil
+		}
+		if isFile {
+			files = append(files, path)
+		} else {
+			assets = append(assets, path)
+		}
+		if opts.Progress != nil {
+			opts.Progress(len(files) + len(assets))
+		}
 		return nil
-	})
+	}
+	if opts.GitTracked || opts.GitUntracked {
+		err = walkTracked(ctx, fsys, absDir, opts.GitUntracked, walk)
+	} else {
+		err = fs.WalkDir(fsys, ".", walk)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("scan markdown files: %w", err)
+		return Inventory{}, fmt.Errorf("scan markdown files: %w", err)
 	}
 
 	sort.Strings(files)
-	return files, nil
+	sort.Strings(assets)
+	return Inventory{Files: files, Assets: assets}, nil
 }
 
+// compileIgnoreRules compiles --ignore rules in gitignore syntax, relative
+// to the scan dir. A leading "./" is accepted for compatibility, and rules
+// without glob characters stay path prefixes of the scan dir, as --ignore
+// values were before gitignore syntax: "drafts" skips only the top-level
+// drafts path.
 func compileIgnoreRules(rules []string) ignoreMatcher {
-	matcher := ignoreMatcher{}
-	if len(rules) == 0 {
-		return matcher
-	}
-
-	matcher.globs = make([]string, 0, len(rules))
-	matcher.prefixes = make([]string, 0, len(rules))
+	matcher := ignoreMatcher{patterns: make([]ignorePattern, 0, len(rules))}
 	for _, raw := range rules {
-		rule := strings.TrimSpace(raw)
-		if rule == "" {
-			continue
-		}
-		rule = filepath.ToSlash(filepath.Clean(rule))
-		if rule == "." {
-			continue
-		}
-		if hasGlob(rule) {
-			matcher.globs = append(matcher.globs, rule)
-			continue
+		rule := strings.TrimPrefix(strings.TrimSpace(raw), "./")
+		if p, ok := compileIgnorePattern(rule); ok {
+			if p.re == nil {
+				p.anchored = true
+			}
+			matcher.patterns = append(matcher.patterns, p)
 		}
-		matcher.prefixes = append(matcher.prefixes, strings.TrimSuffix(rule, "/"))
 	}
 	return matcher
 }
 
-func (m ignoreMatcher) matches(rel string) bool {
-	if rel == "." || (len(m.globs) == 0 && len(m.prefixes) == 0) {
-		return false
-	}
-
-	relSlash := filepath.ToSlash(rel)
-	for _, rule := range m.globs {
-		matched, err := filepath.Match(rule, relSlash)
-		if err == nil && matched {
-			return true
-		}
-	}
-	for _, rule := range m.prefixes {
-		if relSlash == rule || strings.HasPrefix(relSlash, rule+"/") {
-			return true
-		}
+// match reports whether a rule matches the scan-dir relative slash path rel
+// and, if so, whether the last matching rule ignores it.
+func (m ignoreMatcher) match(rel string, isDir bool) (matched, ignored bool) {
+	if len(m.patterns) == 0 {
+		return false, false
 	}
-
-	return false
+	return matchIgnorePatterns(m.patterns, rel, isDir)
 }
 
-func hasGlob(pattern string) bool {
-	return strings.ContainsAny(pattern, "*?[")
+func hasExt(set map[string]struct{}, ext string) bool {
+	_, ok := set[ext]
+	return ok
 }
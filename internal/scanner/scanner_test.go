@@ -1,11 +1,16 @@
 package scanner
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"testing/fstest"
 
-	"gorphan/internal/testutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/testutil"
 )
 
 func TestNormalizeExtensions(t *testing.T) {
@@ -25,7 +30,7 @@ func TestScan_WithIgnoreRules(t *testing.T) {
 	testutil.MustWrite(t, filepath.Join(dir, "assets", "readme.md"), "# assets")
 	testutil.MustWrite(t, filepath.Join(dir, "README.txt"), "not markdown")
 
-	files, err := Scan(Options{
+	files, err := Scan(context.Background(), Options{
 		Dir:        dir,
 		Extensions: []string{".md", ".markdown"},
 		Ignore:     []string{"guide/skip.md", "assets/*"},
@@ -49,7 +54,7 @@ func TestScan_IgnoreDirectoryPrefix(t *testing.T) {
 	testutil.MustWrite(t, filepath.Join(dir, "docs", "index.md"), "# docs")
 	testutil.MustWrite(t, filepath.Join(dir, "drafts", "a.md"), "# a")
 
-	files, err := Scan(Options{
+	files, err := Scan(context.Background(), Options{
 		Dir:        dir,
 		Extensions: []string{".md"},
 		Ignore:     []string{"drafts"},
@@ -63,3 +68,192 @@ func TestScan_IgnoreDirectoryPrefix(t *testing.T) {
 		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", want, files)
 	}
 }
+
+func TestScanInventory_CollectsAssets(t *testing.T) {
+	dir := t.TempDir()
+	testutil.MustWrite(t, filepath.Join(dir, "index.md"), "# index")
+	testutil.MustWrite(t, filepath.Join(dir, "img", "logo.png"), "png")
+	testutil.MustWrite(t, filepath.Join(dir, "files", "manual.pdf"), "pdf")
+	testutil.MustWrite(t, filepath.Join(dir, "files", "notes.txt"), "txt")
+	testutil.MustWrite(t, filepath.Join(dir, "drafts", "old.png"), "png")
+
+	inventory, err := ScanInventory(context.Background(), Options{
+		Dir:        dir,
+		Extensions: []string{".md"},
+		Ignore:     []string{"drafts"},
+		Assets:     []string{"*.png", "files/*.pdf"},
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	wantFiles := []string{filepath.Join(dir, "index.md")}
+	if !reflect.DeepEqual(inventory.Files, wantFiles) {
+		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", wantFiles, inventory.Files)
+	}
+	wantAssets := []string{
+		filepath.Join(dir, "files", "manual.pdf"),
+		filepath.Join(dir, "img", "logo.png"),
+	}
+	if !reflect.DeepEqual(inventory.Assets, wantAssets) {
+		t.Fatalf("unexpected assets\nwant: %#v\n got: %#v", wantAssets, inventory.Assets)
+	}
+}
+
+func TestScanInventory_FS(t *testing.T) {
+	dir := filepath.FromSlash("/virtual/docs")
+	fsys := fstest.MapFS{
+		"index.md":        {Data: []byte("# index")},
+		"guide/intro.md":  {Data: []byte("# intro")},
+		"guide/logo.png":  {Data: []byte("png")},
+		"drafts/draft.md": {Data: []byte("# draft")},
+	}
+
+	inventory, err := ScanInventory(context.Background(), Options{
+		Dir:        dir,
+		FS:         fsys,
+		Extensions: []string{".md"},
+		Ignore:     []string{"drafts"},
+		Assets:     []string{"*.png"},
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	wantFiles := []string{
+		filepath.Join(dir, "guide", "intro.md"),
+		filepath.Join(dir, "index.md"),
+	}
+	if !reflect.DeepEqual(inventory.Files, wantFiles) {
+		t.Fatalf("unexpected files\nwant: %#v\n got: %#v", wantFiles, inventory.Files)
+	}
+	wantAssets := []string{filepath.Join(dir, "guide", "logo.png")}
+	if !reflect.DeepEqual(inventory.Assets, wantAssets) {
+		t.Fatalf("unexpected assets\nwant: %#v\n got: %#v", wantAssets, inventory.Assets)
+	}
+}
+
+func TestScanInventory_ProgressAndCancel(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.md":     {Data: []byte("# a")},
+		"b.md":     {Data: []byte("# b")},
+		"logo.png": {Data: []byte("png")},
+	}
+	opts := Options{Dir: filepath.FromSlash("/docs"), FS: fsys, Extensions: []string{".md"}, Assets: []string{"*.png"}}
+
+	counts := make([]int, 0)
+	opts.Progress = func(scanned int) { counts = append(counts, scanned) }
+	if _, err := ScanInventory(context.Background(), opts); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if !reflect.DeepEqual(counts, []int{1, 2, 3}) {
+		t.Fatalf("unexpected progress counts: %v", counts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ScanInventory(ctx, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestScan_FollowSymlinks(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("eval temp dir: %v", err)
+	}
+	dir := filepath.Join(base, "docs")
+	testutil.MustWrite(t, filepath.Join(dir, "index.md"), "# index")
+	testutil.MustWrite(t, filepath.Join(dir, "real", "page.md"), "# page")
+	testutil.MustWrite(t, filepath.Join(base, "shared", "common.md"), "# shared")
+	if err := os.Symlink(filepath.Join(dir, "real"), filepath.Join(dir, "alias")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	mustSymlink(t, filepath.Join(base, "shared"), filepath.Join(dir, "shared"))
+	mustSymlink(t, dir, filepath.Join(dir, "real", "loop"))
+	mustSymlink(t, filepath.Join(dir, "missing"), filepath.Join(dir, "dangling"))
+
+	files, err := Scan(context.Background(), Options{Dir: dir, Extensions: []string{".md"}})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "index.md"), filepath.Join(dir, "real", "page.md")}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected files without following\nwant: %#v\n got: %#v", want, files)
+	}
+
+	files, err = Scan(context.Background(), Options{Dir: dir, Extensions: []string{".md"}, FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	want = []string{
+		filepath.Join(dir, "index.md"),
+		filepath.Join(dir, "real", "page.md"),
+		filepath.Join(dir, "shared", "common.md"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected files when following\nwant: %#v\n got: %#v", want, files)
+	}
+}
+
+func TestScanInventory_GitTracked(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git unavailable: %v", err)
+	}
+	repo := t.TempDir()
+	dir := filepath.Join(repo, "docs")
+	mustGit(t, repo, "init", "-q")
+	testutil.MustWrite(t, filepath.Join(repo, ".gitignore"), "*.tmp.md\n")
+	testutil.MustWrite(t, filepath.Join(repo, "outside.md"), "# outside")
+	testutil.MustWrite(t, filepath.Join(dir, "index.md"), "# index")
+	testutil.MustWrite(t, filepath.Join(dir, "guide", "staged.md"), "# staged")
+	testutil.MustWrite(t, filepath.Join(dir, "guide", "notes.txt"), "not markdown")
+	testutil.MustWrite(t, filepath.Join(dir, "drafts", "wip.md"), "# wip")
+	testutil.MustWrite(t, filepath.Join(dir, "deleted.md"), "# deleted")
+	mustGit(t, repo, "add", ".")
+	if err := os.Remove(filepath.Join(dir, "deleted.md")); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	testutil.MustWrite(t, filepath.Join(dir, "untracked.md"), "# untracked")
+	testutil.MustWrite(t, filepath.Join(dir, "scratch.tmp.md"), "# scratch")
+
+	opts := Options{Dir: dir, Extensions: []string{".md"}, Ignore: []string{"drafts/"}, GitTracked: true}
+	files, err := Scan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "guide", "staged.md"), filepath.Join(dir, "index.md")}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected tracked files\nwant: %#v\n got: %#v", want, files)
+	}
+
+	opts.GitUntracked = true
+	files, err = Scan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	want = append(want, filepath.Join(dir, "untracked.md"))
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("unexpected tracked and untracked files\nwant: %#v\n got: %#v", want, files)
+	}
+
+	if _, err := Scan(context.Background(), Options{Dir: t.TempDir(), Extensions: []string{".md"}, GitTracked: true}); err == nil {
+		t.Fatalf("expected error outside a git repository")
+	}
+}
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func mustSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+)
+
+// linkWalker follows symlinked directories during a walk of the OS
+// filesystem. Every real directory is entered once, which breaks cycles,
+// and collected files are reported under their canonical path. A nil
+// walker follows nothing.
+type linkWalker struct {
+	dir     string
+	visited map[string]struct{}
+	files   map[string]struct{}
+}
+
+func newLinkWalker(dir string) *linkWalker {
+	return &linkWalker{dir: dir, visited: make(map[string]struct{}), files: make(map[string]struct{})}
+}
+
+// linkedDir reports whether d is a symlink that resolves to a directory.
+func (w *linkWalker) linkedDir(fsys fs.FS, name string, d fs.DirEntry) bool {
+	if w == nil || d.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+	info, err := fs.Stat(fsys, name)
+	return err == nil && info.IsDir()
+}
+
+// enter reports whether the directory at path has not been walked yet.
+func (w *linkWalker) enter(path string) bool {
+	if w == nil {
+		return true
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	if _, ok := w.visited[real]; ok {
+		return false
+	}
+	w.visited[real] = struct{}{}
+	return true
+}
+
+// collect returns the identity of the file at path, and false when a file
+// with that identity was already collected through another route.
+func (w *linkWalker) collect(path string) (string, bool) {
+	if w == nil {
+		return path, true
+	}
+	path = pathutil.Canonical(w.dir, path)
+	if _, ok := w.files[path]; ok {
+		return "", false
+	}
+	w.files[path] = struct{}{}
+	return path, true
+}
@@ -0,0 +1,174 @@
+package slug
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Style string
+
+const (
+	GitHub Style = "github"
+	GitLab Style = "gitlab"
+	Hugo   Style = "hugo"
+	MkDocs Style = "mkdocs"
+)
+
+func ParseStyle(name string) (Style, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "github":
+		return GitHub, nil
+	case "gitlab":
+		return GitLab, nil
+	case "hugo", "goldmark":
+		return Hugo, nil
+	case "mkdocs":
+		return MkDocs, nil
+	}
+	return "", fmt.Errorf("unknown slug style %q (want github, gitlab, hugo, or mkdocs)", name)
+}
+
+// Slugger generates heading IDs for a single document, disambiguating
+// repeated headings the way the selected renderer does.
+type Slugger struct {
+	style Style
+	seen  map[string]struct{}
+}
+
+func New(style Style) *Slugger {
+	return &Slugger{style: style, seen: make(map[string]struct{})}
+}
+
+func (s *Slugger) Reserve(id string) {
+	s.seen[id] = struct{}{}
+}
+
+func (s *Slugger) Slug(text string) string {
+	base := Generate(s.style, text)
+	id := base
+	if s.style == MkDocs {
+		for i := 1; id == "" || s.taken(id); i++ {
+			id = base + "_" + strconv.Itoa(i)
+		}
+	} else {
+		for i := 1; s.taken(id); i++ {
+			id = base + "-" + strconv.Itoa(i)
+		}
+	}
+	s.Reserve(id)
+	return id
+}
+
+func (s *Slugger) taken(id string) bool {
+	_, ok := s.seen[id]
+	return ok
+}
+
+func Generate(style Style, text string) string {
+	switch style {
+	case GitLab:
+		return gitlab(text)
+	case Hugo:
+		return hugo(text)
+	case MkDocs:
+		return mkdocs(text)
+	default:
+		return github(text)
+	}
+}
+
+func github(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case isWordRune(r) || r == '-':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func gitlab(text string) string {
+	var b strings.Builder
+	lastHyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		if r == ' ' {
+			r = '-'
+		}
+		if r != '-' && !isWordRune(r) {
+			continue
+		}
+		if r == '-' && lastHyphen {
+			continue
+		}
+		lastHyphen = r == '-'
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func hugo(text string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteByte('-')
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '-' || r == '_':
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// mkdocs mirrors Python-Markdown's toc slugify: fold to ASCII, drop
+// non-word characters, trim, then collapse whitespace and hyphen runs.
+func mkdocs(text string) string {
+	var kept strings.Builder
+	for _, r := range foldASCII(text) {
+		if r == '_' || r == '-' || unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			kept.WriteRune(r)
+		}
+	}
+
+	var b strings.Builder
+	inSep := false
+	for _, r := range strings.ToLower(strings.TrimSpace(kept.String())) {
+		if r == '-' || unicode.IsSpace(r) {
+			if !inSep {
+				b.WriteByte('-')
+			}
+			inSep = true
+			continue
+		}
+		inSep = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// latin1Fold approximates NFKD+ASCII folding for U+00C0..U+00FF; '.' marks
+// characters without an ASCII decomposition.
+const latin1Fold = "AAAAAA.CEEEEIIII.NOOOOO..UUUUY..aaaaaa.ceeeeiiii.nooooo..uuuuy.y"
+
+func foldASCII(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r < unicode.MaxASCII:
+			b.WriteRune(r)
+		case r >= 0xC0 && r <= 0xFF:
+			if ch := latin1Fold[r-0xC0]; ch != '.' {
+				b.WriteByte(ch)
+			}
+		}
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r) || unicode.Is(unicode.Pc, r)
+}
@@ -0,0 +1,60 @@
+package slug
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerate_Styles(t *testing.T) {
+	cases := []struct {
+		style Style
+		text  string
+		want  string
+	}{
+		{GitHub, "Getting Started", "getting-started"},
+		{GitHub, "What's new in v2.0?", "whats-new-in-v20"},
+		{GitHub, "A  --  B", "a------b"},
+		{GitHub, "snake_case & Café", "snake_case--café"},
+		{GitLab, "A  --  B", "a-b"},
+		{GitLab, "snake_case & Café", "snake_case-café"},
+		{Hugo, " Trimmed\tTabs ", "trimmed-tabs"},
+		{Hugo, "Café 2.0", "café-20"},
+		{MkDocs, "Café & Crème", "cafe-creme"},
+		{MkDocs, "A  --  B", "a-b"},
+		{MkDocs, "Hello, World!", "hello-world"},
+	}
+	for _, tc := range cases {
+		if got := Generate(tc.style, tc.text); got != tc.want {
+			t.Fatalf("%s(%q)\nwant: %q\n got: %q", tc.style, tc.text, tc.want, got)
+		}
+	}
+}
+
+func TestSlugger_Deduplicates(t *testing.T) {
+	github := New(GitHub)
+	github.Reserve("intro-1")
+	var got []string
+	for _, text := range []string{"Intro", "Intro", "Intro"} {
+		got = append(got, github.Slug(text))
+	}
+	want := []string{"intro", "intro-2", "intro-3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected github slugs\nwant: %#v\n got: %#v", want, got)
+	}
+
+	mkdocs := New(MkDocs)
+	got = []string{mkdocs.Slug("Intro"), mkdocs.Slug("Intro"), mkdocs.Slug("!!!")}
+	want = []string{"intro", "intro_1", "_1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected mkdocs slugs\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	if style, err := ParseStyle(" Goldmark "); err != nil || style != Hugo {
+		t.Fatalf("expected goldmark alias for hugo, got %q %v", style, err)
+	}
+	if _, err := ParseStyle("kramdown"); err == nil {
+		t.Fatalf("expected unknown style error")
+	}
+}
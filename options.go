@@ -0,0 +1,439 @@
+package gorphan
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/shogo-nakano-desu/gorphan/internal/graph"
+	"github.com/shogo-nakano-desu/gorphan/internal/nav"
+	"github.com/shogo-nakano-desu/gorphan/internal/parser"
+	"github.com/shogo-nakano-desu/gorphan/internal/pathutil"
+	"github.com/shogo-nakano-desu/gorphan/internal/slug"
+)
+
+// Options configures a Check. Zero values select the same defaults as the
+// CLI; nil slices use the defaults while empty slices disable the feature.
+type Options struct {
+	// Root is the entry page. It defaults to the first MkDocs nav page or to
+	// SUMMARY.md when MkDocs or Summary is set.
+	Root string
+	// Roots adds entry pages; reachability starts from all of them. Roots
+	// and Root may be globs such as "services/*/docs/index.md".
+	Roots []string
+	// Dir is the scan directory. It defaults to the MkDocs docs_dir, the
+	// directory holding SUMMARY.md, or the current directory.
+	Dir string
+	// Dirs adds scan directories. The inventory is the union of every
+	// directory and orphans are reported relative to the deepest one holding
+	// them. Site generator features treat Dir as the site. Dirs cannot be
+	// combined with FS.
+	Dirs []string
+	// SiteRoot is where root-relative links resolve. It defaults to the
+	// enclosing git repository root, or Dir outside a repository or when FS
+	// is set.
+	SiteRoot string
+	// FS holds the pages and assets under Dir, rooted at Dir, for example an
+	// embed.FS or fstest.MapFS. Nil reads the OS filesystem. MkDocs and
+	// Summary are always read from the OS filesystem.
+	FS fs.FS
+	// Extensions lists the page extensions, matched case-insensitively; a
+	// missing leading dot is added. Nil uses DefaultExtensions.
+	Extensions []string
+	// Resolve lists the ordered candidates tried for extensionless and
+	// directory links: suffixes such as ".md" are appended to the link, and
+	// names starting with "/" such as "/index.md" are looked up inside the
+	// linked directory. Nil uses ResolveCandidates(Extensions); empty
+	// disables the lookup.
+	Resolve []string
+	// Ignore lists patterns in gitignore syntax, relative to each scan
+	// directory, for paths to leave out of the scan. A "!" prefix re-includes
+	// a path.
+	Ignore []string
+	// IgnoreCheckFiles lists pages and assets that are never reported as
+	// orphans, by path relative to the scan directory holding them or by
+	// basename.
+	IgnoreCheckFiles []string
+	// Assets lists globs for non-page files, such as "*.png", to inventory
+	// and report when no page links to them. A glob matches the basename or
+	// the path relative to the scan directory. None by default.
+	Assets []string
+	// GitIgnore leaves out paths excluded by .gitignore files, the
+	// repository's .git/info/exclude and the global git excludes file. With
+	// FS set, only .gitignore files inside FS apply.
+	GitIgnore bool
+	// GitTracked takes the candidate pages and assets from git's index,
+	// including staged files, instead of walking Dir. GitUntracked also adds
+	// untracked files that git does not ignore, and implies GitTracked.
+	// Ignore rules and extension filtering still apply. Both need the git
+	// binary and fail with FS set.
+	GitTracked   bool
+	GitUntracked bool
+	// FollowSymlinks walks symlinked directories, entering each real
+	// directory once, and gives every page one identity: its symlink-free
+	// path when that lies inside Dir. It has no effect with FS set.
+	FollowSymlinks bool
+	// Anchors is the heading slug style #fragment links are checked
+	// against: AnchorsGitHub, AnchorsGitLab, AnchorsHugo or AnchorsMkDocs.
+	// The default, AnchorsNone, skips these checks. Wikilink heading and
+	// block refs are checked either way.
+	Anchors AnchorStyle
+	// WikiLinks selects how [[wikilinks]] resolve: WikiLinkRelative (the
+	// default) keeps the path from the linking page, WikiLinkShortest looks
+	// the name up by basename the way Obsidian does. Front matter aliases are
+	// the fallback in both modes.
+	WikiLinks WikiLinkMode
+	// Drafts selects how pages with "draft: true" front matter are handled:
+	// DraftsInclude (the default) treats them like other pages,
+	// DraftsExclude leaves them out of the check, and DraftsNoLinks keeps
+	// them but ignores their outgoing links.
+	Drafts DraftMode
+	// JSXLinks lists "Component:attribute" pairs whose values are link
+	// targets in .mdx files. Nil uses parser.DefaultJSXLinks, which is
+	// "Link:to,a:href"; empty disables JSX links.
+	JSXLinks []string
+	// Includes lists the include directive syntaxes whose targets count as
+	// links: snippets, markdown-include, comment or a regexp. None are read
+	// by default.
+	Includes []string
+	// Hugo selects the Hugo support: HugoNone (the default), HugoRefs
+	// resolves ref and relref shortcodes, and HugoSections also links each
+	// _index.md to the pages of its section.
+	Hugo HugoMode
+	// Jekyll resolves Liquid link, post_url and include tags and front
+	// matter permalinks.
+	Jekyll bool
+	// MyST resolves MyST and Sphinx toctree directives, {doc} and {ref}
+	// roles and (label)= targets.
+	MyST bool
+	// MkDocs is a mkdocs.yml whose nav pages are roots. It defaults Dir to
+	// its docs_dir and Root to the first nav page. It cannot be combined
+	// with Summary.
+	MkDocs string
+	// Summary is an mdBook or GitBook SUMMARY.md listing every built page;
+	// pages it does not list are orphans even when linked. It defaults Dir
+	// to its directory and Root to the file itself.
+	Summary string
+	// Workers caps the goroutines that parse pages. Zero uses GOMAXPROCS.
+	Workers int
+	// Progress, when set, is called from a single goroutine as files are
+	// scanned and parsed.
+	Progress func(Progress)
+
+	// navPages holds the MkDocs nav or Summary chapters Normalize loaded.
+	navPages []nav.Entry
+}
+
+var (
+	DefaultExtensions = []string{".md", ".markdown"}
+	DefaultResolve    = ResolveCandidates(DefaultExtensions)
+)
+
+// ResolveCandidates returns the default Resolve candidates for extensions:
+// every extension as a suffix, then index.*, README.* and _index.* inside the
+// linked directory with each extension.
+func ResolveCandidates(extensions []string) []string {
+	exts := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if !slices.Contains(exts, ext) {
+			exts = append(exts, ext)
+		}
+	}
+	candidates := slices.Clone(exts)
+	for _, name := range []string{"/index", "/README", "/_index"} {
+		for _, ext := range exts {
+			candidates = append(candidates, name+ext)
+		}
+	}
+	return candidates
+}
+
+// Normalize applies the defaults, rejects unknown mode values and makes every
+// path absolute. Afterwards
+// Roots and Dirs list every root and scan directory, with Root and Dir their
+// first entries, and root globs are expanded. It does not check that the
+// paths exist; Check reports missing files and directories.
+func Normalize(opts Options) (Options, error) {
+	if strings.TrimSpace(opts.MkDocs) != "" && strings.TrimSpace(opts.Summary) != "" {
+		return Options{}, fmt.Errorf("mkdocs and summary cannot be combined")
+	}
+	if strings.TrimSpace(opts.MkDocs) != "" {
+		mkdocsAbs, err := pathutil.NormalizeAbs(opts.MkDocs)
+		if err != nil {
+			return Options{}, fmt.Errorf("resolve mkdocs config: %w", err)
+		}
+		site, err := nav.LoadMkDocs(mkdocsAbs)
+		if err != nil {
+			return Options{}, err
+		}
+		opts.MkDocs = mkdocsAbs
+		opts.navPages = site.Entries
+		if strings.TrimSpace(opts.Dir) == "" {
+			opts.Dir = site.DocsDir
+		}
+		if strings.TrimSpace(opts.Root) == "" && len(site.Entries) > 0 {
+			opts.Root = site.Entries[0].Path
+		}
+	}
+	if strings.TrimSpace(opts.Summary) != "" {
+		summaryAbs, err := pathutil.NormalizeAbs(opts.Summary)
+		if err != nil {
+			return Options{}, fmt.Errorf("resolve summary: %w", err)
+		}
+		entries, err := nav.LoadSummary(summaryAbs)
+		if err != nil {
+			return Options{}, err
+		}
+		opts.Summary = summaryAbs
+		opts.navPages = entries
+		if strings.TrimSpace(opts.Dir) == "" {
+			opts.Dir = filepath.Dir(summaryAbs)
+		}
+		if strings.TrimSpace(opts.Root) == "" {
+			opts.Root = summaryAbs
+		}
+	}
+	dirs, err := normalizePaths(opts.Dir, opts.Dirs)
+	if err != nil {
+		return Options{}, fmt.Errorf("resolve dir: %w", err)
+	}
+	if len(dirs) == 0 {
+		if dirs, err = normalizePaths(".", nil); err != nil {
+			return Options{}, fmt.Errorf("resolve dir: %w", err)
+		}
+	}
+	if len(dirs) > 1 && opts.FS != nil {
+		return Options{}, fmt.Errorf("multiple dirs cannot be combined with FS")
+	}
+	dirAbs := dirs[0]
+	opts.Dir, opts.Dirs = dirAbs, dirs
+	roots, err := normalizePaths(opts.Root, opts.Roots)
+	if err != nil {
+		return Options{}, fmt.Errorf("resolve root: %w", err)
+	}
+	if roots, err = expandRootGlobs(opts.FS, dirAbs, roots); err != nil {
+		return Options{}, err
+	}
+	opts.Root, opts.Roots = "", roots
+	if len(roots) > 0 {
+		opts.Root = roots[0]
+	}
+	switch {
+	case strings.TrimSpace(opts.SiteRoot) != "":
+		if opts.SiteRoot, err = pathutil.NormalizeAbs(opts.SiteRoot); err != nil {
+			return Options{}, fmt.Errorf("resolve site root: %w", err)
+		}
+	default:
+		opts.SiteRoot = dirAbs
+		if opts.FS != nil {
+			break
+		}
+		if repoRoot, ok := pathutil.FindRepoRoot(dirAbs); ok {
+			opts.SiteRoot = repoRoot
+		}
+	}
+
+	if opts.FollowSymlinks && opts.FS == nil {
+		for i, dir := range opts.Dirs {
+			opts.Dirs[i] = evalSymlinks(dir)
+		}
+		opts.Dir = opts.Dirs[0]
+		opts.SiteRoot = evalSymlinks(opts.SiteRoot)
+		for i, root := range opts.Roots {
+			opts.Roots[i] = pathutil.CanonicalIn(opts.Dirs, root)
+		}
+		if len(opts.Roots) > 0 {
+			opts.Root = opts.Roots[0]
+		}
+	}
+
+	if opts.Extensions == nil {
+		opts.Extensions = slices.Clone(DefaultExtensions)
+	}
+	if opts.Resolve == nil {
+		opts.Resolve = ResolveCandidates(opts.Extensions)
+	}
+	if opts.JSXLinks == nil {
+		opts.JSXLinks = pathutil.SplitList(parser.DefaultJSXLinks)
+	}
+	if err := opts.normalizeModes(); err != nil {
+		return Options{}, err
+	}
+	return opts, nil
+}
+
+// normalizeModes lowercases Anchors, WikiLinks, Drafts and Hugo, fills in
+// their defaults and rejects unknown values.
+func (o *Options) normalizeModes() error {
+	o.Anchors = AnchorStyle(normalizeMode(string(o.Anchors)))
+	switch o.Anchors {
+	case "":
+		o.Anchors = AnchorsNone
+	case AnchorsNone:
+	default:
+		style, err := slug.ParseStyle(string(o.Anchors))
+		if err != nil {
+			return fmt.Errorf("anchors must be one of: github, gitlab, hugo, mkdocs, none")
+		}
+		o.Anchors = style
+	}
+	o.WikiLinks = WikiLinkMode(normalizeMode(string(o.WikiLinks)))
+	switch o.WikiLinks {
+	case "":
+		o.WikiLinks = WikiLinkRelative
+	case WikiLinkRelative, WikiLinkShortest:
+	default:
+		return fmt.Errorf("wikilinks must be one of: relative, shortest")
+	}
+	o.Drafts = DraftMode(normalizeMode(string(o.Drafts)))
+	switch o.Drafts {
+	case "":
+		o.Drafts = DraftsInclude
+	case DraftsInclude, DraftsExclude, DraftsNoLinks:
+	default:
+		return fmt.Errorf("drafts must be one of: include, exclude, no-links")
+	}
+	o.Hugo = HugoMode(normalizeMode(string(o.Hugo)))
+	switch o.Hugo {
+	case "":
+		o.Hugo = HugoNone
+	case HugoNone, HugoRefs, HugoSections:
+	default:
+		return fmt.Errorf("hugo must be one of: none, refs, sections")
+	}
+	return nil
+}
+
+func normalizeMode(mode string) string {
+	return strings.ToLower(strings.TrimSpace(mode))
+}
+
+// normalizePaths makes first and rest absolute, dropping blanks and
+// duplicates while keeping their order.
+func normalizePaths(first string, rest []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(rest)+1)
+	out := make([]string, 0, len(rest)+1)
+	for _, path := range append([]string{first}, rest...) {
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+		abs, err := pathutil.NormalizeAbs(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[abs]; ok {
+			continue
+		}
+		seen[abs] = struct{}{}
+		out = append(out, abs)
+	}
+	return out, nil
+}
+
+// expandRootGlobs replaces root patterns with the files they match, in
+// sorted order. With fsys set, patterns under dir are matched inside fsys.
+func expandRootGlobs(fsys fs.FS, dir string, roots []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(roots))
+	out := make([]string, 0, len(roots))
+	for _, root := range roots {
+		matches := []string{root}
+		if hasGlobMeta(root) {
+			var err error
+			if matches, err = globRoot(fsys, dir, root); err != nil {
+				return nil, fmt.Errorf("expand root %q: %w", root, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("root pattern matches no files: %s", root)
+			}
+		}
+		for _, match := range matches {
+			if _, ok := seen[match]; ok {
+				continue
+			}
+			seen[match] = struct{}{}
+			out = append(out, match)
+		}
+	}
+	return out, nil
+}
+
+func globRoot(fsys fs.FS, dir, pattern string) ([]string, error) {
+	if fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	name, ok := pathutil.FSPath(dir, pattern)
+	if !ok {
+		return nil, nil
+	}
+	matches, err := fs.Glob(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	for i, match := range matches {
+		matches[i] = filepath.Join(dir, filepath.FromSlash(match))
+	}
+	return matches, nil
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// evalSymlinks resolves symlinks in path, keeping path when it does not
+// exist; Check reports missing paths later.
+func evalSymlinks(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
+
+func (o Options) anchorStyle() slug.Style {
+	if o.Anchors == AnchorsNone {
+		return ""
+	}
+	return o.Anchors
+}
+
+// scanProgress reports scanned counts on top of offset files already found
+// in earlier directories.
+func (o Options) scanProgress(offset int) func(int) {
+	if o.Progress == nil {
+		return nil
+	}
+	return func(scanned int) { o.Progress(Progress{Scanned: offset + scanned}) }
+}
+
+func (o Options) parseProgress(scanned int) func(int, int) {
+	if o.Progress == nil {
+		return nil
+	}
+	return func(parsed, total int) { o.Progress(Progress{Scanned: scanned, Parsed: parsed, Total: total}) }
+}
+
+func (o Options) extractors() (parser.Extractors, error) {
+	jsxLinks, err := parser.ParseJSXLinks(o.JSXLinks)
+	if err != nil {
+		return nil, err
+	}
+	includes, err := parser.ParseIncludePatterns(o.Includes)
+	if err != nil {
+		return nil, err
+	}
+	return parser.DefaultExtractors(parser.Options{
+		JSXLinks: jsxLinks,
+		Hugo:     o.Hugo != graph.HugoNone,
+		Jekyll:   o.Jekyll,
+		MyST:     o.MyST,
+		Includes: includes,
+	}), nil
+}